@@ -0,0 +1,394 @@
+// Command rtf converts and inspects RTF documents.
+//
+// Usage:
+//
+//	rtf convert [--format html] [--pretty] [--unit pt|px|em|rem] [--base-font-size pt] [--images dir] [--image-url url] [--tab-stops] [--style-classes] [--document] [--xhtml] <input> [output]
+//	rtf tokens <input>
+//	rtf ops <input>
+//	rtf layout <input>
+//	rtf validate <input>
+//
+// An input or output path of "-" reads from stdin or writes to stdout. The
+// output path of convert defaults to stdout. Pictures are inlined in the output
+// unless --images names a directory to write them to, linked relative to the
+// output file or with the URL given by --image-url. With --tab-stops, text is
+// laid out at the tab stops of its paragraph. With --style-classes, the styles
+// of the stylesheet become CSS classes. With --document, the output is a full
+// HTML document titled after the document information. With --xhtml, the output
+// is well-formed XHTML. The tokens and validate commands read the input as a
+// stream, in constant memory.
+//
+// Exit codes:
+//
+//	0   success
+//	1   I/O or other failure
+//	2   invalid command line
+//	10+ parsing error, 10 plus the parser.ParsingErrorKind of the failure
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"rtf-parser/html"
+	"rtf-parser/layout"
+	"rtf-parser/lexer"
+	"rtf-parser/parser"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+
+	exitParsingErrorBase = 10
+)
+
+const (
+	formatHTML = "html"
+)
+
+var (
+	unitLookup = map[string]layout.MeasuringUnit{
+		"pt":  layout.MeasuringUnitPoint,
+		"px":  layout.MeasuringUnitPixel,
+		"em":  layout.MeasuringUnitEm,
+		"rem": layout.MeasuringUnitRem,
+	}
+)
+
+type (
+	command struct {
+		name  string
+		usage string
+		run   func(args []string) int
+	}
+)
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "convert", usage: "[--format html] [--pretty] [--unit pt|px|em|rem] [--base-font-size pt] [--images dir] [--image-url url] [--tab-stops] [--style-classes] [--document] [--xhtml] <input> [output]", run: runConvert},
+		{name: "tokens", usage: "<input>", run: runTokens},
+		{name: "ops", usage: "<input>", run: runOps},
+		{name: "layout", usage: "<input>", run: runLayout},
+		{name: "validate", usage: "<input>", run: runValidate},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitUsage
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "rtf: unknown command %q\n", args[0])
+	usage()
+	return exitUsage
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\trtf %s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(os.Stderr, "usage: rtf %s %s\n", cmd.name, cmd.usage)
+			}
+		}
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func runConvert(args []string) int {
+	flags := newFlagSet("convert")
+	format := flags.String("format", formatHTML, "output format (html)")
+	pretty := flags.Bool("pretty", false, "break lines between tags")
+	unit := flags.String("unit", "pt", "unit of the output lengths (pt, px, em, rem)")
+	baseFontSize := flags.Float64("base-font-size", layout.DefaultBaseFontSize, "font size in points em and rem lengths are relative to")
+	images := flags.String("images", "", "write pictures to this directory instead of inlining them")
+	imageURL := flags.String("image-url", "", "link pictures with this URL of the --images directory (default: its path relative to the output)")
+	tabStops := flags.Bool("tab-stops", false, "lay out text at the tab stops of its paragraph")
+	styleClasses := flags.Bool("style-classes", false, "render the document styles as CSS classes")
+	document := flags.Bool("document", false, "output a full HTML document with a head")
+	xhtml := flags.Bool("xhtml", false, "output well-formed XHTML")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	outputUnit, exist := unitLookup[*unit]
+	if !exist {
+		fmt.Fprintf(os.Stderr, "rtf: unknown unit %q\n", *unit)
+		return exitUsage
+	}
+
+	tabStrategy := html.TabStrategyCharacter
+	if *tabStops {
+		tabStrategy = html.TabStrategyStops
+	}
+
+	outputPath := "-"
+	if flags.NArg() == 2 {
+		outputPath = flags.Arg(1)
+	}
+
+	// Pictures are linked relative to the output, where the HTML is read
+	if *images != "" && *imageURL == "" {
+		*imageURL = filepath.ToSlash(*images)
+		if outputPath != "-" {
+			if rel, err := relativePath(filepath.Dir(outputPath), *images); err == nil {
+				*imageURL = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	ops, code := parseInput(flags.Arg(0))
+	if code != exitOK {
+		return code
+	}
+
+	var output string
+	switch *format {
+	case formatHTML:
+		options := html.BuilderOptions{
+			PrettyOutput: *pretty,
+			Unit:         outputUnit,
+			BaseFontSize: *baseFontSize,
+			ImageDir:     *images,
+			ImageURL:     *imageURL,
+			StyleClasses: *styleClasses,
+			TabStrategy:  tabStrategy,
+			FullDocument: *document,
+			XHTML:        *xhtml,
+		}
+		if info, exist := parser.FindDocumentInfo(ops); exist {
+			options.Info = &info
+		}
+
+		var err error
+		output, err = html.OutputHTML(layout.BuildLayout(ops), options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rtf: %s\n", err)
+			return exitFailure
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "rtf: unknown format %q\n", *format)
+		return exitUsage
+	}
+
+	if err := writeOutput(outputPath, output); err != nil {
+		fmt.Fprintf(os.Stderr, "rtf: %s\n", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func runTokens(args []string) int {
+	flags := newFlagSet("tokens")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	input, err := openInput(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rtf: %s\n", err)
+		return exitFailure
+	}
+	defer input.Close()
+
+	l := lexer.NewReader(input)
+	for {
+		token := l.NextToken()
+		lexer.PrintToken(token)
+		if token.Kind() == lexer.TokenEOF {
+			break
+		}
+	}
+
+	if err := l.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "rtf: %s\n", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func runOps(args []string) int {
+	flags := newFlagSet("ops")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	ops, code := parseInput(flags.Arg(0))
+	if code != exitOK {
+		return code
+	}
+
+	parser.DebugOpStream(ops)
+	return exitOK
+}
+
+func runLayout(args []string) int {
+	flags := newFlagSet("layout")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	ops, code := parseInput(flags.Arg(0))
+	if code != exitOK {
+		return code
+	}
+
+	layout.DebugLayout(layout.BuildLayout(ops))
+	return exitOK
+}
+
+func runValidate(args []string) int {
+	flags := newFlagSet("validate")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	// The document is parsed as it is read, without holding its entities
+	input, err := openInput(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rtf: %s\n", err)
+		return exitFailure
+	}
+	defer input.Close()
+
+	p := parser.NewReader(input, parser.ParsingOptions{})
+	for {
+		_, err := p.Next()
+		if err == io.EOF {
+			return exitOK
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rtf: %s: %s\n", flags.Arg(0), err)
+			return exitCode(err)
+		}
+	}
+}
+
+// parseInput reads and parses the document at path. On failure the error is
+// reported on stderr and the returned exit code is non-zero.
+func parseInput(path string) ([]parser.Entity, int) {
+	input, err := readInput(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rtf: %s\n", err)
+		return nil, exitFailure
+	}
+
+	ops, err := parser.Parse(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rtf: %s: %s\n", path, err)
+		return nil, exitCode(err)
+	}
+
+	return ops, exitOK
+}
+
+func exitCode(err error) int {
+	var parsingErr parser.ParsingError
+	if errors.As(err, &parsingErr) {
+		return exitParsingErrorBase + int(parsingErr.Kind())
+	}
+
+	return exitFailure
+}
+
+func readInput(path string) (string, error) {
+	var (
+		input []byte
+		err   error
+	)
+
+	if path == "-" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(path)
+	}
+
+	return string(input), err
+}
+
+// relativePath returns the path of target relative to the base directory,
+// either of them being absolute or relative to the working directory
+func relativePath(base string, target string) (string, error) {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Rel(base, target)
+}
+
+// openInput opens the document at path for reading as a stream
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(path)
+}
+
+func writeOutput(path string, output string) error {
+	if path == "-" {
+		_, err := io.WriteString(os.Stdout, output)
+		return err
+	}
+
+	return os.WriteFile(path, []byte(output), 0644)
+}
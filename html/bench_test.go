@@ -0,0 +1,59 @@
+package html
+
+import (
+	"testing"
+
+	"rtf-parser/internal/samples"
+	"rtf-parser/layout"
+	"rtf-parser/parser"
+)
+
+var (
+	// Allocation targets of converting the sample documents, from parsing to
+	// the HTML output, a few percent over the measured counts
+	convertAllocsTargets = map[string]float64{
+		"simple.rtf":  97,
+		"regular.rtf": 530,
+	}
+)
+
+func convert(input string) (string, error) {
+	ops, err := parser.Parse(input)
+	if err != nil {
+		return "", err
+	}
+
+	return OutputHTML(layout.BuildLayout(ops), BuilderOptions{})
+}
+
+func BenchmarkConvert(b *testing.B) {
+	for name, input := range samples.Inputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i += 1 {
+				if _, err := convert(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertAllocations(t *testing.T) {
+	if samples.RaceEnabled {
+		t.Skip("the race detector changes allocations")
+	}
+
+	for name, input := range samples.Inputs(t) {
+		target, exist := convertAllocsTargets[name]
+		if !exist {
+			t.Errorf("%s: no allocation target", name)
+			continue
+		}
+
+		if allocs := testing.AllocsPerRun(10, func() { convert(input) }); allocs > target {
+			t.Errorf("%s: converting allocates %.0f times, target %.0f", name, allocs, target)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package html
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+var (
+	textEscaper = strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+	)
+
+	attributeEscaper = strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		`"`, "&quot;",
+		"'", "&#39;",
+	)
+
+	// URL schemes a link may use, others like javascript: are dropped
+	safeURLSchemes = map[string]bool{
+		"http":   true,
+		"https":  true,
+		"mailto": true,
+		"ftp":    true,
+		"tel":    true,
+		"file":   true,
+	}
+)
+
+// escapeText escapes a text for the content of an element. In XHTML mode, the
+// characters XML does not allow are dropped.
+func (builder *Builder) escapeText(text string) string {
+	if builder.opt.XHTML {
+		text = strings.Map(xmlCharacter, text)
+	}
+	return textEscaper.Replace(text)
+}
+
+// escapeAttribute escapes a value for a double or single quoted attribute
+func (builder *Builder) escapeAttribute(value string) string {
+	if builder.opt.XHTML {
+		value = strings.Map(xmlCharacter, value)
+	}
+	return attributeEscaper.Replace(value)
+}
+
+// xmlCharacter drops the characters XML 1.0 forbids, mostly control
+// characters
+func xmlCharacter(r rune) rune {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return r
+	case r < 0x20, r == 0xfffe, r == 0xffff, r >= 0xd800 && r <= 0xdfff:
+		return -1
+	}
+	return r
+}
+
+// cssString quotes a value, like a font name, as a CSS string. Characters that
+// could end the string, the declaration or an enclosing style element are
+// written as CSS escapes.
+func cssString(value string) string {
+	builder := strings.Builder{}
+	builder.WriteByte('"')
+
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\' || r == '<' || r == '>' || r == '&' || r == '\'':
+			fmt.Fprintf(&builder, "\\%x ", r)
+		case unicode.IsControl(r):
+			// Line breaks may not appear in a string, and other control
+			// characters have no place in a name
+			if r == '\n' || r == '\r' || r == '\f' {
+				fmt.Fprintf(&builder, "\\%x ", r)
+			}
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// safeHref returns the href of a link, or an empty string when its scheme
+// could run script or is unknown.
+func safeHref(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	if u.Scheme != "" && !safeURLSchemes[strings.ToLower(u.Scheme)] {
+		return ""
+	}
+
+	return href
+}
@@ -0,0 +1,829 @@
+// Package html renders a document layout as an HTML fragment, or as a full
+// document with BuilderOptions.FullDocument.
+package html
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"rtf-parser/layout"
+	"rtf-parser/parser"
+)
+
+var (
+	// Units lengths may be written in, by their CSS name
+	cssUnits = map[layout.MeasuringUnit]string{
+		layout.MeasuringUnitPoint: "pt",
+		layout.MeasuringUnitPixel: "px",
+		layout.MeasuringUnitEm:    "em",
+		layout.MeasuringUnitRem:   "rem",
+	}
+
+	borderProperties = [layout.BorderSideMAX]string{
+		layout.BorderSideTop:    "border-top",
+		layout.BorderSideLeft:   "border-left",
+		layout.BorderSideBottom: "border-bottom",
+		layout.BorderSideRight:  "border-right",
+	}
+)
+
+type (
+	Builder struct {
+		opt      BuilderOptions
+		buf      strings.Builder
+		styleBuf strings.Builder
+		err      error
+
+		// Class names of the styles used so far, in order of first use
+		styleClasses map[*layout.Style]string
+		usedStyles   []*layout.Style
+		// Style of the paragraph being rendered, its formats are inherited by
+		// the text runs
+		paragraphStyle *layout.Style
+	}
+
+	BuilderOptions struct {
+		PrettyOutput bool
+		// Unit lengths are written in: points, pixels, em or rem. Points
+		// are used for any other unit.
+		Unit layout.MeasuringUnit
+		// BaseFontSize is the font size in points em and rem lengths are
+		// relative to, layout.DefaultBaseFontSize when 0
+		BaseFontSize float64
+		// ImageDir is the directory images are written to, named after a
+		// hash of their content so that documents sharing the directory do
+		// not overwrite each other's images. When empty, images are inlined
+		// as data URIs.
+		ImageDir string
+		// ImageURL is the URL of ImageDir as seen from the HTML output, which
+		// images are linked with. When empty, images are linked by their
+		// path.
+		ImageURL string
+		// StyleClasses renders the paragraph and character styles of the
+		// stylesheet as classes of a <style> element. Only the formatting a
+		// paragraph or run does not get from its styles is left inline.
+		StyleClasses bool
+		// FullDocument wraps the output in an html document, with the style
+		// element of StyleClasses in its head.
+		FullDocument bool
+		// Info fills the title and meta elements of a full document
+		Info *parser.DocumentInfo
+		// TabStrategy is how tabs are rendered
+		TabStrategy TabStrategy
+		// XHTML makes the output well-formed XML: empty elements are closed,
+		// characters XML forbids are dropped and paragraphs holding other
+		// blocks are written as div elements.
+		XHTML bool
+	}
+)
+
+// OutputHTML renders the layout as an HTML fragment. It only fails when
+// writing an image to BuilderOptions.ImageDir fails.
+func OutputHTML(nodes []layout.LayoutNode, options BuilderOptions) (string, error) {
+	builder := Builder{
+		opt:          options,
+		styleClasses: map[*layout.Style]string{},
+	}
+
+	for _, root := range nodes {
+		builder.outputNodeHTML(root)
+	}
+
+	if builder.err != nil {
+		return "", builder.err
+	}
+
+	styleSheet := ""
+	if len(builder.usedStyles) > 0 {
+		styleSheet = builder.outputStyleSheet()
+	}
+
+	if builder.opt.FullDocument {
+		return builder.outputDocument(styleSheet), nil
+	}
+
+	return styleSheet + builder.buf.String(), nil
+}
+
+// outputDocument wraps the rendered body in a full html document
+func (builder *Builder) outputDocument(styleSheet string) string {
+	document := strings.Builder{}
+	newline := func() {
+		if builder.opt.PrettyOutput {
+			document.WriteByte('\n')
+		}
+	}
+
+	document.WriteString("<!DOCTYPE html>")
+	newline()
+	if builder.opt.XHTML {
+		document.WriteString("<html xmlns=\"http://www.w3.org/1999/xhtml\">")
+	} else {
+		document.WriteString("<html>")
+	}
+	newline()
+	document.WriteString("<head>")
+	newline()
+	fmt.Fprintf(&document, "<meta charset=\"utf-8\"%s", builder.emptyTagEnd())
+	newline()
+
+	title := ""
+	if builder.opt.Info != nil {
+		title = builder.opt.Info.Title()
+	}
+	fmt.Fprintf(&document, "<title>%s</title>", builder.escapeText(title))
+	newline()
+
+	for _, meta := range documentMeta(builder.opt.Info) {
+		fmt.Fprintf(&document, "<meta name=\"%s\" content=\"%s\"%s", meta[0], builder.escapeAttribute(meta[1]), builder.emptyTagEnd())
+		newline()
+	}
+
+	document.WriteString(styleSheet)
+	document.WriteString("</head>")
+	newline()
+	document.WriteString("<body>")
+	newline()
+	document.WriteString(builder.buf.String())
+	document.WriteString("</body>")
+	newline()
+	document.WriteString("</html>")
+	newline()
+
+	return document.String()
+}
+
+// documentMeta returns the name and content of the meta elements describing
+// the document, leaving out the properties it does not record.
+func documentMeta(info *parser.DocumentInfo) [][2]string {
+	if info == nil {
+		return nil
+	}
+
+	meta := [][2]string{}
+	add := func(name string, content string) {
+		if content != "" {
+			meta = append(meta, [2]string{name, content})
+		}
+	}
+	// Times carry no zone, the document does not record it
+	addTime := func(name string, t time.Time) {
+		if !t.IsZero() {
+			add(name, t.Format("2006-01-02T15:04:05"))
+		}
+	}
+	addCount := func(name string, count int) {
+		if count > 0 {
+			add(name, fmt.Sprint(count))
+		}
+	}
+
+	add("author", info.Author())
+	add("description", info.Subject())
+	add("keywords", info.Keywords())
+	add("company", info.Company())
+	add("category", info.Category())
+	addTime("dcterms.created", info.Created())
+	addTime("dcterms.modified", info.Revised())
+	addCount("page-count", info.Pages())
+	addCount("word-count", info.Words())
+	addCount("character-count", info.Characters())
+
+	return meta
+}
+
+// TODO(nico): Indent the html correctly
+func (builder *Builder) outputNodeHTML(node layout.LayoutNode) {
+	switch r := node.(type) {
+	case *layout.LayoutParagraph:
+		outerStyle := builder.paragraphStyle
+		builder.paragraphStyle = r.Style()
+		defer func() { builder.paragraphStyle = outerStyle }()
+
+		tag := "p"
+		if builder.opt.XHTML && hasBlockChildren(r) {
+			tag = "div"
+		}
+
+		builder.openHTMLTag(tag, builder.outputStyledAttributes(r.Format(), r.Style(), nil, true))
+		defer builder.closeHTMLTag(tag)
+		if builder.outputTabbedChildren(r) {
+			break
+		}
+		for _, child := range r.Children() {
+			builder.outputNodeHTML(child)
+		}
+
+	case *layout.LayoutText:
+		builder.openHTMLTag("span", builder.outputStyledAttributes(r.Format(), r.Style(), builder.paragraphStyle, false))
+		defer builder.closeHTMLTag("span")
+
+		// Superscript and subscript use their own elements, which also shrink
+		// the text
+		if v, ok := r.Format()[layout.FormatVerticalAlign].(layout.VerticalAlign); ok {
+			switch v.Kind {
+			case layout.VerticalAlignSuperscript:
+				builder.openHTMLTag("sup", "")
+				defer builder.closeHTMLTag("sup")
+			case layout.VerticalAlignSubscript:
+				builder.openHTMLTag("sub", "")
+				defer builder.closeHTMLTag("sub")
+			}
+		}
+
+		builder.buf.WriteString(builder.escapeText(r.Value()))
+
+	case *layout.LayoutTab:
+		builder.outputTab()
+
+	case *layout.LayoutLineBreak:
+		fmt.Fprintf(&builder.buf, "<br%s", builder.emptyTagEnd())
+		if builder.opt.PrettyOutput {
+			builder.buf.WriteByte('\n')
+		}
+
+	case *layout.LayoutImage:
+		builder.outputImageHTML(r)
+
+	case *layout.LayoutLink:
+		builder.styleBuf.Reset()
+		if href := safeHref(r.Href()); href != "" {
+			fmt.Fprintf(&builder.styleBuf, "href=\"%s\"", builder.escapeAttribute(href))
+		}
+		if r.Title() != "" {
+			if builder.styleBuf.Len() > 0 {
+				builder.styleBuf.WriteByte(' ')
+			}
+			fmt.Fprintf(&builder.styleBuf, "title=\"%s\"", builder.escapeAttribute(r.Title()))
+		}
+		builder.openHTMLTag("a", builder.styleBuf.String())
+		defer builder.closeHTMLTag("a")
+		for _, child := range r.Children() {
+			builder.outputNodeHTML(child)
+		}
+
+	case *layout.LayoutList:
+		tag := "ul"
+		if r.Ordered() {
+			tag = "ol"
+		}
+
+		builder.styleBuf.Reset()
+		if r.Ordered() && r.Start() != 1 {
+			fmt.Fprintf(&builder.styleBuf, "start=\"%d\"", r.Start())
+		}
+		if r.StyleType() != layout.ListStyleDecimal && r.StyleType() != layout.ListStyleDisc {
+			if builder.styleBuf.Len() > 0 {
+				builder.styleBuf.WriteByte(' ')
+			}
+			fmt.Fprintf(&builder.styleBuf, "style=\"list-style-type: %s;\"", r.StyleType())
+		}
+
+		builder.openHTMLTag(tag, builder.styleBuf.String())
+		defer builder.closeHTMLTag(tag)
+		for _, item := range r.Items() {
+			builder.outputNodeHTML(item)
+		}
+
+	case *layout.LayoutListItem:
+		builder.openHTMLTag("li", "")
+		defer builder.closeHTMLTag("li")
+		for _, child := range r.Children() {
+			builder.outputNodeHTML(child)
+		}
+
+	case *layout.LayoutTable:
+		builder.openHTMLTag("table", builder.outputTableStyleCSS(r))
+		defer builder.closeHTMLTag("table")
+		for _, row := range r.Rows() {
+			builder.outputNodeHTML(row)
+		}
+
+	case *layout.LayoutTableRow:
+		builder.styleBuf.Reset()
+		if r.Height() != 0 {
+			builder.styleBuf.WriteString("style=\"")
+			fmt.Fprintf(&builder.styleBuf, "height: %s;", builder.length(abs(r.Height()), layout.MeasuringUnitTwip))
+			builder.styleBuf.WriteString("\"")
+		}
+		builder.openHTMLTag("tr", builder.styleBuf.String())
+		defer builder.closeHTMLTag("tr")
+		for _, cell := range r.Cells() {
+			// Covered by the span of a merged cell
+			if cell.Merged() {
+				continue
+			}
+			builder.outputNodeHTML(cell)
+		}
+
+	case *layout.LayoutTableCell:
+		builder.openHTMLTag("td", builder.outputCellAttributes(r))
+		defer builder.closeHTMLTag("td")
+		for _, child := range r.Children() {
+			builder.outputNodeHTML(child)
+		}
+	}
+}
+
+func (builder *Builder) outputImageHTML(img *layout.LayoutImage) {
+	src, err := builder.imageSource(img)
+	if err != nil {
+		if builder.err == nil {
+			builder.err = err
+		}
+		return
+	}
+
+	builder.styleBuf.Reset()
+	if img.Width() > 0 && img.Height() > 0 {
+		fmt.Fprintf(&builder.styleBuf, " style=\"width: %s;height: %s;\"", builder.length(img.Width(), layout.MeasuringUnitTwip), builder.length(img.Height(), layout.MeasuringUnitTwip))
+	}
+
+	fmt.Fprintf(&builder.buf, "<img src=\"%s\"%s%s", builder.escapeAttribute(src), builder.styleBuf.String(), builder.emptyTagEnd())
+	if builder.opt.PrettyOutput {
+		builder.buf.WriteByte('\n')
+	}
+}
+
+// imageSource returns the src attribute of an image, writing the image to
+// ImageDir when one is set.
+func (builder *Builder) imageSource(img *layout.LayoutImage) (string, error) {
+	if builder.opt.ImageDir == "" {
+		return fmt.Sprintf("data:%s;base64,%s", img.ImageFormat().MIMEType(), base64.StdEncoding.EncodeToString(img.Data())), nil
+	}
+
+	hash := sha256.Sum256(img.Data())
+	name := fmt.Sprintf("image-%x.%s", hash[:8], img.ImageFormat().Extension())
+	path := filepath.Join(builder.opt.ImageDir, name)
+
+	if err := os.MkdirAll(builder.opt.ImageDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, img.Data(), 0644); err != nil {
+		return "", err
+	}
+
+	if builder.opt.ImageURL == "" {
+		return filepath.ToSlash(path), nil
+	}
+	return strings.TrimSuffix(builder.opt.ImageURL, "/") + "/" + url.PathEscape(name), nil
+}
+
+func (builder *Builder) openHTMLTag(tag string, style string) {
+	if style == "" {
+		fmt.Fprintf(&builder.buf, "<%s>", tag)
+	} else {
+		fmt.Fprintf(&builder.buf, "<%s %s>", tag, style)
+	}
+	if builder.opt.PrettyOutput {
+		builder.buf.WriteByte('\n')
+	}
+}
+
+// emptyTagEnd returns the end of an element without content, like img, which
+// XHTML requires to be closed
+func (builder *Builder) emptyTagEnd() string {
+	if builder.opt.XHTML {
+		return " />"
+	}
+	return ">"
+}
+
+// hasBlockChildren reports whether a paragraph holds paragraphs, lists or
+// tables, which may not be nested in a p element
+func hasBlockChildren(p *layout.LayoutParagraph) bool {
+	for _, child := range p.Children() {
+		switch child.Kind() {
+		case layout.LayoutNodeParagraph, layout.LayoutNodeList, layout.LayoutNodeTable:
+			return true
+		}
+	}
+	return false
+}
+
+func (builder *Builder) closeHTMLTag(tag string) {
+	fmt.Fprintf(&builder.buf, "</%s>", tag)
+	if builder.opt.PrettyOutput {
+		builder.buf.WriteByte('\n')
+	}
+}
+
+// outputStyledAttributes returns the class and style attributes of a
+// paragraph or text run. With StyleClasses, the node gets the class of its own
+// style and the declarations it inherits from its style and the one of its
+// paragraph are left out, while the properties the node turns off are written
+// back to their default.
+func (builder *Builder) outputStyledAttributes(format layout.Format, style *layout.Style, paragraphStyle *layout.Style, paragraph bool) string {
+	if !builder.opt.StyleClasses || (style == nil && paragraphStyle == nil) {
+		return builder.outputStyleCSS(format)
+	}
+
+	base := layout.Format{}
+	for _, s := range []*layout.Style{paragraphStyle, style} {
+		if s != nil {
+			base = base.Merge(s.Format)
+		}
+	}
+
+	resolved := format
+	for k, f := range format {
+		if f == base[k] {
+			format[k] = nil
+			continue
+		}
+
+		// The parts of an indentation the style already sets are left to it
+		if indent, ok := f.(layout.TextIndent); ok {
+			own, _ := base[k].(layout.TextIndent)
+			if indent.Value == own.Value {
+				indent.Value = 0
+			}
+			if indent.FirstLineOffset == own.FirstLineOffset {
+				indent.FirstLineOffset = 0
+			}
+			format[k] = indent
+		}
+	}
+
+	builder.styleBuf.Reset()
+	builder.writeDeclarations(format)
+	builder.writeDefaultDeclarations(base, resolved, paragraph)
+
+	styleAttribute := builder.styleAttribute()
+	if style == nil {
+		return styleAttribute
+	}
+
+	classAttribute := fmt.Sprintf("class=\"%s\"", builder.styleClass(style))
+	if styleAttribute == "" {
+		return classAttribute
+	}
+	return classAttribute + " " + styleAttribute
+}
+
+// styleClass returns the class name of a style, named after the style and
+// made unique among the styles of the document.
+func (builder *Builder) styleClass(style *layout.Style) string {
+	if class, exist := builder.styleClasses[style]; exist {
+		return class
+	}
+
+	name := strings.Builder{}
+	dash := false
+	for _, r := range strings.ToLower(style.Name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && name.Len() > 0 {
+				name.WriteByte('-')
+			}
+			name.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	// Class selectors may not start with a digit
+	class := name.String()
+	if class == "" || unicode.IsDigit(rune(class[0])) {
+		class = "style-" + class
+	}
+
+	unique := class
+	for i := 2; builder.classTaken(unique); i += 1 {
+		unique = fmt.Sprintf("%s-%d", class, i)
+	}
+
+	builder.styleClasses[style] = unique
+	builder.usedStyles = append(builder.usedStyles, style)
+	return unique
+}
+
+func (builder *Builder) classTaken(class string) bool {
+	for _, taken := range builder.styleClasses {
+		if taken == class {
+			return true
+		}
+	}
+	return false
+}
+
+// outputStyleSheet returns the <style> element holding a rule for each style
+// used by the document.
+func (builder *Builder) outputStyleSheet() string {
+	sheet := strings.Builder{}
+	sheet.WriteString("<style>")
+	if builder.opt.PrettyOutput {
+		sheet.WriteByte('\n')
+	}
+
+	for _, style := range builder.usedStyles {
+		builder.styleBuf.Reset()
+		builder.writeDeclarations(style.Format)
+		fmt.Fprintf(&sheet, ".%s {%s}", builder.styleClasses[style], builder.styleBuf.String())
+		if builder.opt.PrettyOutput {
+			sheet.WriteByte('\n')
+		}
+	}
+
+	sheet.WriteString("</style>")
+	if builder.opt.PrettyOutput {
+		sheet.WriteByte('\n')
+	}
+
+	return sheet.String()
+}
+
+// outputStyleCSS returns the style attribute for a format, or an empty string
+// when no property is set.
+func (builder *Builder) outputStyleCSS(format layout.Format) string {
+	builder.styleBuf.Reset()
+
+	if format == (layout.Format{}) {
+		return ""
+	}
+
+	builder.writeDeclarations(format)
+
+	return builder.styleAttribute()
+}
+
+// styleAttribute returns the style attribute holding the declarations of the
+// style buffer, or an empty string when there are none.
+func (builder *Builder) styleAttribute() string {
+	// Some formats are rendered with elements rather than declarations
+	if builder.styleBuf.Len() == 0 {
+		return ""
+	}
+
+	return "style=\"" + builder.escapeAttribute(builder.styleBuf.String()) + "\""
+}
+
+// writeDeclarations writes the CSS declarations of a format to the style
+// buffer.
+func (builder *Builder) writeDeclarations(format layout.Format) {
+	for _, f := range format {
+		if f == nil {
+			continue
+		}
+
+		terminateStyle := true
+
+		switch _f := f.(type) {
+		case layout.Font:
+			fmt.Fprintf(&builder.styleBuf, "font-family: %s", cssString(_f.Name))
+		case layout.TextStyle:
+			builder.buildTextStyleCSS(_f)
+			terminateStyle = false
+		case layout.Color:
+			builder.buildColorCSS("color", _f)
+		case layout.FontSize:
+			fmt.Fprintf(&builder.styleBuf, "font-size: %s", builder.length(int(_f), layout.MeasuringUnitHalfPoint))
+		case layout.FontWeight:
+			fmt.Fprintf(&builder.styleBuf, "font-weight: %s", _f)
+		case layout.UnderlineStyle:
+			fmt.Fprintf(&builder.styleBuf, "text-decoration-style: %s", _f)
+		case layout.UnderlineColor:
+			builder.buildColorCSS("text-decoration-color", layout.Color(_f))
+		case layout.VerticalAlign:
+			if _f.Kind != layout.VerticalAlignOffset {
+				terminateStyle = false
+				break
+			}
+			fmt.Fprintf(&builder.styleBuf, "vertical-align: %s", builder.length(_f.Offset, layout.MeasuringUnitHalfPoint))
+		case layout.Highlight:
+			builder.buildColorCSS("background-color", _f.Color)
+		case layout.BackgroundColor:
+			// A highlight is drawn over the shading
+			if format[layout.FormatHighlight] != nil {
+				terminateStyle = false
+				break
+			}
+			builder.buildColorCSS("background-color", _f.Color)
+		case layout.ParagraphBackgroundColor:
+			builder.buildColorCSS("background-color", _f.Color)
+		case layout.TextAlign:
+			fmt.Fprintf(&builder.styleBuf, "text-align: %s", _f)
+		case layout.SpaceBefore:
+			fmt.Fprintf(&builder.styleBuf, "margin-top: %s", builder.length(_f.Twips(), layout.MeasuringUnitTwip))
+		case layout.SpaceAfter:
+			fmt.Fprintf(&builder.styleBuf, "margin-bottom: %s", builder.length(_f.Twips(), layout.MeasuringUnitTwip))
+		case layout.LineHeight:
+			if _f.Multiple && _f.Value > 0 {
+				fmt.Fprintf(&builder.styleBuf, "line-height: %s", formatNumber(float64(_f.Value)/240))
+				break
+			}
+			// CSS has no minimum line height, the height is used as it is
+			fmt.Fprintf(&builder.styleBuf, "line-height: %s", builder.length(abs(_f.Value), layout.MeasuringUnitTwip))
+		case layout.TabStops:
+			// Only used to place the content of tabbed paragraphs
+			terminateStyle = false
+		case layout.TextIndent:
+			// \li indents the whole paragraph and \fi its first line
+			if _f.Value != 0 {
+				fmt.Fprintf(&builder.styleBuf, "padding-left: %s;", builder.length(_f.Value, _f.Unit))
+			}
+			if _f.FirstLineOffset != 0 {
+				fmt.Fprintf(&builder.styleBuf, "text-indent: %s;", builder.length(_f.FirstLineOffset, _f.Unit))
+			}
+			terminateStyle = false
+		}
+
+		if terminateStyle {
+			builder.styleBuf.WriteByte(';')
+		}
+	}
+}
+
+// writeDefaultDeclarations writes the default value of the properties a style
+// class sets and the resolved format of a paragraph or text run leaves off,
+// like the weight of a \b0 run in a bold style.
+func (builder *Builder) writeDefaultDeclarations(base layout.Format, resolved layout.Format, paragraph bool) {
+	// A highlight and a shading share the background
+	background := false
+
+	for k, f := range base {
+		// The node only holds the properties of its level
+		if f == nil || layout.IsParagraphFormatKind(layout.FormatKind(k)) != paragraph {
+			continue
+		}
+
+		switch _f := f.(type) {
+		case layout.TextStyle:
+			own, _ := resolved[k].(layout.TextStyle)
+			if _f.Has(layout.TextStyleItalic) && !own.Has(layout.TextStyleItalic) {
+				builder.styleBuf.WriteString("font-style: normal;")
+			}
+			if (_f.Has(layout.TextStyleUnderline) || _f.Has(layout.TextStyleStrike)) &&
+				!own.Has(layout.TextStyleUnderline) && !own.Has(layout.TextStyleStrike) {
+				builder.styleBuf.WriteString("text-decoration-line: none;")
+			}
+			continue
+		case layout.TextIndent:
+			own, _ := resolved[k].(layout.TextIndent)
+			if _f.Value != 0 && own.Value == 0 {
+				builder.styleBuf.WriteString("padding-left: 0;")
+			}
+			if _f.FirstLineOffset != 0 && own.FirstLineOffset == 0 {
+				builder.styleBuf.WriteString("text-indent: 0;")
+			}
+			continue
+		}
+
+		if resolved[k] != nil {
+			continue
+		}
+
+		switch _f := f.(type) {
+		case layout.Color:
+			builder.styleBuf.WriteString("color: initial;")
+		case layout.FontWeight:
+			builder.styleBuf.WriteString("font-weight: normal;")
+		case layout.UnderlineStyle:
+			builder.styleBuf.WriteString("text-decoration-style: solid;")
+		case layout.UnderlineColor:
+			builder.styleBuf.WriteString("text-decoration-color: currentColor;")
+		case layout.VerticalAlign:
+			if _f.Kind == layout.VerticalAlignOffset {
+				builder.styleBuf.WriteString("vertical-align: baseline;")
+			}
+		case layout.Highlight, layout.BackgroundColor, layout.ParagraphBackgroundColor:
+			if !background {
+				builder.styleBuf.WriteString("background-color: transparent;")
+				background = true
+			}
+		case layout.SpaceBefore:
+			builder.styleBuf.WriteString("margin-top: 0;")
+		case layout.SpaceAfter:
+			builder.styleBuf.WriteString("margin-bottom: 0;")
+		case layout.LineHeight:
+			builder.styleBuf.WriteString("line-height: normal;")
+		}
+	}
+}
+
+func (builder *Builder) outputTableStyleCSS(t *layout.LayoutTable) string {
+	builder.styleBuf.Reset()
+	builder.styleBuf.WriteString("style=\"border-collapse: collapse;")
+
+	switch t.Align() {
+	case layout.TableAlignCenter:
+		builder.styleBuf.WriteString("margin-left: auto;margin-right: auto;")
+	case layout.TableAlignRight:
+		builder.styleBuf.WriteString("margin-left: auto;")
+	default:
+		if t.Left() != 0 {
+			fmt.Fprintf(&builder.styleBuf, "margin-left: %s;", builder.length(t.Left(), layout.MeasuringUnitTwip))
+		}
+	}
+
+	builder.styleBuf.WriteString("\"")
+	return builder.styleBuf.String()
+}
+
+// outputCellAttributes returns the span and style attributes of a cell, or an
+// empty string when it has none.
+func (builder *Builder) outputCellAttributes(c *layout.LayoutTableCell) string {
+	builder.styleBuf.Reset()
+
+	if c.ColSpan() > 1 {
+		fmt.Fprintf(&builder.styleBuf, "colspan=\"%d\" ", c.ColSpan())
+	}
+	if c.RowSpan() > 1 {
+		fmt.Fprintf(&builder.styleBuf, "rowspan=\"%d\" ", c.RowSpan())
+	}
+
+	builder.styleBuf.WriteString("style=\"")
+	styleStart := builder.styleBuf.Len()
+
+	if c.Width() > 0 {
+		fmt.Fprintf(&builder.styleBuf, "width: %s;", builder.length(c.Width(), layout.MeasuringUnitTwip))
+	}
+
+	for side, property := range borderProperties {
+		border := c.Border(layout.BorderSide(side))
+		if border.Style == layout.BorderStyleNone {
+			continue
+		}
+
+		fmt.Fprintf(&builder.styleBuf, "%s: %s %s ", property, builder.length(border.Width, layout.MeasuringUnitTwip), border.Style)
+		if border.AutoColor {
+			builder.styleBuf.WriteString("currentColor")
+		} else {
+			fmt.Fprintf(&builder.styleBuf, "rgba(%d, %d, %d, %.1f)", border.Color.R, border.Color.G, border.Color.B, float64(border.Color.A)/255)
+		}
+		builder.styleBuf.WriteByte(';')
+	}
+
+	if bg := c.Background(); bg != nil {
+		builder.buildColorCSS("background-color", *bg)
+		builder.styleBuf.WriteByte(';')
+	}
+
+	if c.VerticalAlign() != layout.CellAlignTop {
+		fmt.Fprintf(&builder.styleBuf, "vertical-align: %s;", c.VerticalAlign())
+	}
+
+	if builder.styleBuf.Len() == styleStart {
+		// Drop the empty style attribute and the space before it
+		attributes := strings.TrimSuffix(builder.styleBuf.String(), "style=\"")
+		return strings.TrimSuffix(attributes, " ")
+	}
+	builder.styleBuf.WriteString("\"")
+
+	return builder.styleBuf.String()
+}
+
+func (builder *Builder) buildColorCSS(property string, c layout.Color) {
+	fmt.Fprintf(&builder.styleBuf, "%s: rgba(%d, %d, %d, %.1f)", property, c.R, c.G, c.B, float64(c.A)/255)
+}
+
+func (builder *Builder) buildTextStyleCSS(f layout.TextStyle) {
+	if f.Has(layout.TextStyleItalic) {
+		fmt.Fprintf(&builder.styleBuf, "font-style: italic;")
+	}
+
+	// Underline and strike share a single declaration
+	if f.Has(layout.TextStyleUnderline) || f.Has(layout.TextStyleStrike) {
+		builder.styleBuf.WriteString("text-decoration-line:")
+		if f.Has(layout.TextStyleUnderline) {
+			builder.styleBuf.WriteString(" underline")
+		}
+		if f.Has(layout.TextStyleStrike) {
+			builder.styleBuf.WriteString(" line-through")
+		}
+		builder.styleBuf.WriteByte(';')
+	}
+}
+
+// length formats a measure as a CSS length in the output unit. Values are
+// rounded to 4 decimals, finer than any renderer needs.
+func (builder *Builder) length(value int, from layout.MeasuringUnit) string {
+	unit := builder.opt.Unit
+	suffix, supported := cssUnits[unit]
+	if !supported {
+		unit = layout.MeasuringUnitPoint
+		suffix = cssUnits[unit]
+	}
+
+	return formatNumber(layout.ConvertUnits(float64(value), from, unit, builder.opt.BaseFontSize)) + suffix
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10000)/10000, 'f', -1, 64)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package html
+
+import (
+	"strings"
+	"testing"
+
+	"rtf-parser/layout"
+	"rtf-parser/parser"
+)
+
+func convertWithOptions(t *testing.T, input string, options BuilderOptions) string {
+	t.Helper()
+
+	ops, err := parser.Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := OutputHTML(layout.BuildLayout(ops), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestStyleClassOverrides(t *testing.T) {
+	sheet := `{\stylesheet{\s1\b\i\ul\sb240 Heading 1;}}`
+	tests := []struct {
+		name  string
+		body  string
+		want  string
+		avoid string
+	}{
+		{name: "bold off", body: `\pard\s1\b0 T\par`, want: `<span style="font-weight: normal;">T</span>`},
+		{name: "italic off", body: `\pard\s1\i0 T\par`, want: `font-style: normal;`},
+		{name: "underline off", body: `\pard\s1\ul0 T\par`, want: `text-decoration-line: none;`},
+		{name: "space off", body: `\pard\s1\sb0 T\par`, want: `<p class="heading-1" style="margin-top: 0;">`},
+		{name: "same as style", body: `\pard\s1 T\par`, want: `<p class="heading-1"><span>T</span>`},
+		{name: "levels kept apart", body: `\pard\s1\b0 T\par`, avoid: `<p class="heading-1" style=`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := convertWithOptions(t, `{\rtf1\ansi`+sheet+test.body+`}`, BuilderOptions{StyleClasses: true})
+			if test.want != "" && !strings.Contains(output, test.want) {
+				t.Errorf("output %s does not contain %s", output, test.want)
+			}
+			if test.avoid != "" && strings.Contains(output, test.avoid) {
+				t.Errorf("output %s contains %s", output, test.avoid)
+			}
+		})
+	}
+}
+
+func TestImageNames(t *testing.T) {
+	dir := t.TempDir()
+	options := BuilderOptions{ImageDir: dir, ImageURL: "img/"}
+
+	first := convertWithOptions(t, `{\rtf1\ansi\pard{\pict\pngblip\picw1\pich1 89504e470d0a1a0a01}\par}`, options)
+	second := convertWithOptions(t, `{\rtf1\ansi\pard{\pict\pngblip\picw1\pich1 89504e470d0a1a0a02}\par}`, options)
+
+	source := func(output string) string {
+		start := strings.Index(output, `src="`)
+		if start < 0 {
+			t.Fatalf("no image in %s", output)
+		}
+		source := output[start+len(`src="`):]
+		return source[:strings.IndexByte(source, '"')]
+	}
+
+	// Images of separate documents written to the same directory do not
+	// overwrite each other
+	if source(first) == source(second) {
+		t.Errorf("both images are written to %s", source(first))
+	}
+	for _, output := range []string{first, second} {
+		if !strings.HasPrefix(source(output), "img/image-") {
+			t.Errorf("image source %s is not under the image URL", source(output))
+		}
+	}
+}
+
+func TestFontFamily(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		want  string
+		avoid string
+	}{
+		{name: "plain name", body: `\f0 T`, want: `font-family: &quot;Courier New&quot;;`},
+		{name: "keyword name", body: `\f1 T`, want: `font-family: &quot;inherit&quot;;`},
+		{name: "generic name", body: `\f2 T`, want: `font-family: &quot;serif&quot;;`},
+		{name: "unknown font", body: `\f9 T`, avoid: `font-family`},
+		{name: "unnamed font", body: `\f3 T`, avoid: `font-family`},
+		{name: "unknown font keeps outer one", body: `\f0 {\f9 T}`, want: `font-family: &quot;Courier New&quot;;`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := `{\rtf1\ansi{\fonttbl{\f0\fmodern Courier New;}{\f1\fnil inherit;}{\f2\froman serif;}{\f3\fnil;}}\pard ` + test.body + `\par}`
+			output := convertWithOptions(t, input, BuilderOptions{})
+			if test.want != "" && !strings.Contains(output, test.want) {
+				t.Errorf("output does not contain %s:\n%s", test.want, output)
+			}
+			if test.avoid != "" && strings.Contains(output, test.avoid) {
+				t.Errorf("output contains %s:\n%s", test.avoid, output)
+			}
+		})
+	}
+}
+
+func TestTextIndent(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		options BuilderOptions
+	}{
+		{name: "left indent", input: `\pard\li720 T\par`, want: `<p style="padding-left: 36pt;">`},
+		{name: "first line", input: `\pard\fi360 T\par`, want: `<p style="text-indent: 18pt;">`},
+		{name: "hanging", input: `\pard\fi-360\li720 T\par`, want: `<p style="padding-left: 36pt;text-indent: -18pt;">`},
+		{name: "zero", input: `\pard\li0\fi0 T\par`, want: `<p><span>`},
+		{name: "left indent reset", input: `\pard\li720\li0 T\par`, want: `<p><span>`},
+		{name: "style reset", input: `\pard\s1\li0 T\par`, want: `<p class="quote" style="padding-left: 0;">`, options: BuilderOptions{StyleClasses: true}},
+		{name: "style first line", input: `\pard\s1\fi360 T\par`, want: `<p class="quote" style="text-indent: 18pt;">`, options: BuilderOptions{StyleClasses: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := convertWithOptions(t, `{\rtf1\ansi{\stylesheet{\s1\li720 Quote;}}`+test.input+`}`, test.options)
+			if !strings.Contains(output, test.want) {
+				t.Errorf("output does not contain %s:\n%s", test.want, output)
+			}
+		})
+	}
+}
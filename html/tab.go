@@ -0,0 +1,182 @@
+package html
+
+import (
+	"fmt"
+
+	"rtf-parser/layout"
+)
+
+const (
+	// Interval of the default tab stops after the last stop of a paragraph,
+	// in twips
+	defaultTabInterval = 720
+)
+
+const (
+	// TabStrategyCharacter writes tabs as tab characters with their white
+	// space preserved, which browsers expand to a fixed width
+	TabStrategyCharacter TabStrategy = iota
+	// TabStrategyStops approximates the tab stops of a paragraph: the text
+	// between tabs is laid out in inline boxes placed and aligned at the
+	// stops, with leaders drawn as a bottom border.
+	TabStrategyStops
+)
+
+var (
+	// Border approximating each tab leader, with its width in twips
+	tabLeaderBorders = map[layout.TabLeader]struct {
+		style string
+		width int
+	}{
+		layout.TabLeaderDot:       {style: "dotted", width: 15},
+		layout.TabLeaderMiddleDot: {style: "dotted", width: 15},
+		layout.TabLeaderHyphen:    {style: "dashed", width: 15},
+		layout.TabLeaderUnderline: {style: "solid", width: 15},
+		layout.TabLeaderThick:     {style: "solid", width: 30},
+		layout.TabLeaderEqual:     {style: "double", width: 45},
+	}
+)
+
+type (
+	TabStrategy int
+
+	// tabBox is the box holding the content between two tabs, from start to
+	// end twips after the first line indent. A negative end leaves the box
+	// as wide as the next box start allows.
+	tabBox struct {
+		start  int
+		end    int
+		align  layout.TabAlign
+		leader layout.TabLeader
+	}
+)
+
+// outputTab writes a tab character that is not laid out at a tab stop
+func (builder *Builder) outputTab() {
+	builder.openHTMLTag("span", "style=\"white-space: pre;\"")
+	builder.buf.WriteByte('\t')
+	builder.closeHTMLTag("span")
+}
+
+// outputTabbedChildren writes the content of a paragraph holding tabs, each
+// run of content between tabs in its own box. It returns false when the
+// paragraph is left to the default rendering.
+func (builder *Builder) outputTabbedChildren(p *layout.LayoutParagraph) bool {
+	if builder.opt.TabStrategy != TabStrategyStops {
+		return false
+	}
+
+	segments := [][]layout.LayoutNode{{}}
+	for _, child := range p.Children() {
+		if child.Kind() == layout.LayoutNodeTab {
+			segments = append(segments, []layout.LayoutNode{})
+			continue
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], child)
+	}
+	if len(segments) == 1 {
+		return false
+	}
+
+	boxes := tabBoxes(p.Format(), len(segments))
+	for i, segment := range segments {
+		builder.openHTMLTag("span", builder.outputTabBoxStyle(boxes, i))
+		for _, child := range segment {
+			builder.outputNodeHTML(child)
+		}
+		builder.closeHTMLTag("span")
+	}
+
+	return true
+}
+
+// tabBoxes places the content before the first tab and after each tab of a
+// paragraph. Text after a left tab starts at its stop, while text after a
+// right, decimal or center tab ends or is centered at its stop, starting from
+// the previous stop.
+func tabBoxes(format layout.Format, count int) []tabBox {
+	stops, _ := format[layout.FormatTabStops].(layout.TabStops)
+
+	// Stops are measured from the margin, the boxes from the start of the
+	// first line
+	origin := 0
+	if indent, ok := format[layout.FormatTextIndent].(layout.TextIndent); ok {
+		origin = indent.Value + indent.FirstLineOffset
+	}
+
+	boxes := []tabBox{{start: 0, end: -1}}
+	previous := 0
+	for i := 1; i < count; i += 1 {
+		stop := tabStopAt(stops.Stops(), i-1)
+		position := max(stop.Position-origin, previous)
+		box := tabBox{start: position, end: -1, align: stop.Align, leader: stop.Leader}
+
+		switch stop.Align {
+		case layout.TabAlignRight, layout.TabAlignDecimal:
+			box.start, box.end = previous, position
+		case layout.TabAlignCenter:
+			box.start, box.end = previous, 2*position-previous
+		}
+
+		boxes = append(boxes, box)
+		previous = position
+	}
+
+	return boxes
+}
+
+// tabStopAt returns the stop the tab at index moves to, following the
+// default stops once the stops of the paragraph are used
+func tabStopAt(stops []layout.TabStop, index int) layout.TabStop {
+	if index < len(stops) {
+		return stops[index]
+	}
+
+	last := 0
+	if len(stops) > 0 {
+		last = stops[len(stops)-1].Position
+	}
+	next := (last/defaultTabInterval + index - len(stops) + 1) * defaultTabInterval
+
+	return layout.TabStop{Position: next}
+}
+
+func (builder *Builder) outputTabBoxStyle(boxes []tabBox, index int) string {
+	box := boxes[index]
+	last := index == len(boxes)-1
+
+	builder.styleBuf.Reset()
+	builder.styleBuf.WriteString("style=\"display: inline-block;white-space: pre;")
+
+	end := box.end
+	if end < 0 && !last {
+		end = boxes[index+1].start
+	}
+	if end >= 0 {
+		fmt.Fprintf(&builder.styleBuf, "width: %s;", builder.length(max(end-box.start, 0), layout.MeasuringUnitTwip))
+		if !last && boxes[index+1].start != end {
+			fmt.Fprintf(&builder.styleBuf, "margin-right: %s;", builder.length(boxes[index+1].start-end, layout.MeasuringUnitTwip))
+		}
+	}
+
+	switch box.align {
+	case layout.TabAlignCenter:
+		builder.styleBuf.WriteString("text-align: center;")
+	case layout.TabAlignRight, layout.TabAlignDecimal:
+		builder.styleBuf.WriteString("text-align: right;")
+	}
+
+	// The leader of a left stop fills the end of the box before it
+	leader := box.leader
+	if !last && boxes[index+1].align == layout.TabAlignLeft {
+		leader = boxes[index+1].leader
+	} else if box.align == layout.TabAlignLeft {
+		leader = layout.TabLeaderNone
+	}
+	if border, exist := tabLeaderBorders[leader]; exist {
+		fmt.Fprintf(&builder.styleBuf, "border-bottom: %s %s currentColor;", builder.length(border.width, layout.MeasuringUnitTwip), border.style)
+	}
+
+	builder.styleBuf.WriteString("\"")
+	return builder.styleBuf.String()
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package samples
+
+// RaceEnabled reports whether the race detector is on, which changes the
+// allocations of the code under test
+const RaceEnabled = false
@@ -0,0 +1,35 @@
+// Package samples loads the sample documents of the input directory, for the
+// tests and benchmarks of the other packages.
+package samples
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Inputs returns the content of the sample documents by file name
+func Inputs(tb testing.TB) map[string]string {
+	tb.Helper()
+
+	_, file, _, _ := runtime.Caller(0)
+	paths, err := filepath.Glob(filepath.Join(filepath.Dir(file), "..", "..", "input", "*.rtf"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(paths) == 0 {
+		tb.Fatal("no sample document found")
+	}
+
+	inputs := map[string]string{}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		inputs[filepath.Base(path)] = string(content)
+	}
+
+	return inputs
+}
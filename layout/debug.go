@@ -0,0 +1,107 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	layoutNodeKindStr = map[LayoutNodeKind]string{
+		LayoutNodeInvalid:   "Invalid",
+		LayoutNodeParagraph: "Paragraph",
+		LayoutNodeText:      "Text",
+		LayoutNodeTable:     "Table",
+		LayoutNodeTableRow:  "Table Row",
+		LayoutNodeTableCell: "Table Cell",
+		LayoutNodeImage:     "Image",
+		LayoutNodeLink:      "Link",
+		LayoutNodeList:      "List",
+		LayoutNodeListItem:  "List Item",
+		LayoutNodeTab:       "Tab",
+		LayoutNodeLineBreak: "Line Break",
+	}
+)
+
+func DebugLayout(nodes []LayoutNode) {
+	builder := strings.Builder{}
+
+	for _, node := range nodes {
+		debugLayoutNode(&builder, node, 0)
+	}
+
+	fmt.Println(builder.String())
+}
+
+func debugLayoutNode(builder *strings.Builder, node LayoutNode, indent int) {
+	for i := 0; i < indent; i += 1 {
+		builder.WriteByte('\t')
+	}
+
+	builder.WriteString(layoutNodeKindStr[node.Kind()])
+
+	format := node.Format()
+	for _, f := range format {
+		if f == nil {
+			continue
+		}
+		fmt.Fprintf(builder, " %T=%v", f, f)
+	}
+
+	switch n := node.(type) {
+	case *LayoutParagraph:
+		if n.style != nil {
+			fmt.Fprintf(builder, " (style: %q)", n.style.Name)
+		}
+		builder.WriteByte('\n')
+		for _, child := range n.children {
+			debugLayoutNode(builder, child, indent+1)
+		}
+	case *LayoutText:
+		if n.style != nil {
+			fmt.Fprintf(builder, " (style: %q)", n.style.Name)
+		}
+		fmt.Fprintf(builder, ` (value: "%s")`, n.value)
+		builder.WriteByte('\n')
+	case *LayoutTab, *LayoutLineBreak:
+		builder.WriteByte('\n')
+	case *LayoutImage:
+		fmt.Fprintf(builder, " (type: %s, width: %d, height: %d, length: %d)", n.imageFormat.MIMEType(), n.width, n.height, len(n.data))
+		builder.WriteByte('\n')
+	case *LayoutLink:
+		fmt.Fprintf(builder, ` (href: "%s")`, n.href)
+		builder.WriteByte('\n')
+		for _, child := range n.children {
+			debugLayoutNode(builder, child, indent+1)
+		}
+	case *LayoutList:
+		fmt.Fprintf(builder, " (style: %s, start: %d, level: %d)", n.styleType, n.start, n.level)
+		builder.WriteByte('\n')
+		for _, item := range n.items {
+			debugLayoutNode(builder, item, indent+1)
+		}
+	case *LayoutListItem:
+		builder.WriteByte('\n')
+		for _, child := range n.children {
+			debugLayoutNode(builder, child, indent+1)
+		}
+	case *LayoutTable:
+		builder.WriteByte('\n')
+		for _, row := range n.rows {
+			debugLayoutNode(builder, row, indent+1)
+		}
+	case *LayoutTableRow:
+		if n.height != 0 {
+			fmt.Fprintf(builder, " (height: %d)", n.height)
+		}
+		builder.WriteByte('\n')
+		for _, cell := range n.cells {
+			debugLayoutNode(builder, cell, indent+1)
+		}
+	case *LayoutTableCell:
+		fmt.Fprintf(builder, " (width: %d, colspan: %d, rowspan: %d, merged: %t)", n.width, n.colSpan, n.rowSpan, n.merged)
+		builder.WriteByte('\n')
+		for _, child := range n.children {
+			debugLayoutNode(builder, child, indent+1)
+		}
+	}
+}
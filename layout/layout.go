@@ -0,0 +1,659 @@
+// Package layout resolves the flat entity stream produced by the parser into
+// a tree of formatted paragraphs and text runs.
+package layout
+
+import (
+	"slices"
+	"strings"
+
+	"rtf-parser/parser"
+)
+
+const (
+	// Default \up and \dn offset, in half-points
+	defaultBaselineOffset = 6
+
+	// Space Word leaves above and below paragraphs with \sbauto and \saauto,
+	// in twips
+	autoParagraphSpacing = 280
+
+	// Twips per pixel of a bitmap picture, at 96 DPI
+	twipsPerPixel = 15
+)
+
+var (
+	imageFormatLookup = map[parser.PictureFormatKind]ImageFormat{
+		parser.PictureFormatPNG:  ImageFormatPNG,
+		parser.PictureFormatJPEG: ImageFormatJPEG,
+		parser.PictureFormatEMF:  ImageFormatEMF,
+		parser.PictureFormatWMF:  ImageFormatWMF,
+	}
+)
+
+var (
+	// Word highlighter colors, indexed from 1
+	highlightPalette = []Color{
+		{R: 0, G: 0, B: 0, A: 255},       // Black
+		{R: 0, G: 0, B: 255, A: 255},     // Blue
+		{R: 0, G: 255, B: 255, A: 255},   // Cyan
+		{R: 0, G: 255, B: 0, A: 255},     // Green
+		{R: 255, G: 0, B: 255, A: 255},   // Magenta
+		{R: 255, G: 0, B: 0, A: 255},     // Red
+		{R: 255, G: 255, B: 0, A: 255},   // Yellow
+		{R: 255, G: 255, B: 255, A: 255}, // White
+		{R: 0, G: 0, B: 128, A: 255},     // Dark blue
+		{R: 0, G: 128, B: 128, A: 255},   // Dark cyan
+		{R: 0, G: 128, B: 0, A: 255},     // Dark green
+		{R: 128, G: 0, B: 128, A: 255},   // Dark magenta
+		{R: 128, G: 0, B: 0, A: 255},     // Dark red
+		{R: 128, G: 128, B: 0, A: 255},   // Dark yellow
+		{R: 128, G: 128, B: 128, A: 255}, // Dark gray
+		{R: 192, G: 192, B: 192, A: 255}, // Light gray
+	}
+)
+
+type (
+	Layout struct {
+		ops               []parser.Entity
+		previous          parser.Entity
+		current           parser.Entity
+		formatStack       []FormatOp
+		formatStackFrames []int
+		fontTable         map[int]Font
+		colorTable        []Color
+
+		// Output
+		roots       []LayoutNode
+		currentNode *LayoutParagraph
+		groupNodes  []*LayoutParagraph
+		// Text node the last texts were merged into, with its value being
+		// built
+		lastText      *LayoutText
+		lastTextValue strings.Builder
+
+		// Tables
+		tableDepth int
+		// Open tables, outermost first
+		tables []*tableState
+		// Last \trowd definition by table depth
+		rowDefinitions map[int]parser.TableRow
+		// Paragraph started by the last \pard, which \intbl may move into a cell
+		pendingParagraph *LayoutParagraph
+
+		// Fields whose result is being laid out, innermost last
+		fields []fieldState
+
+		// Alignment and leader given for the next \tx
+		pendingTabStop TabStop
+
+		// Lists
+		lists map[int]parser.List
+		// List ids by \lsN index
+		listOverrides map[int]int
+		// Items laid out so far by list and level, to number lists that
+		// resume after other paragraphs
+		listCounters map[listKey]int
+
+		// Resolved styles of the stylesheet
+		styles map[styleKey]*Style
+	}
+
+	fieldState struct {
+		field parser.Field
+		// Group nesting of the \field group
+		depth int
+		link  *LayoutLink
+	}
+)
+
+func BuildLayout(ops []parser.Entity) []LayoutNode {
+	layout := Layout{
+		ops:            slices.Clone(ops),
+		fontTable:      map[int]Font{},
+		rowDefinitions: map[int]parser.TableRow{},
+		lists:          map[int]parser.List{},
+		listOverrides:  map[int]int{},
+		listCounters:   map[listKey]int{},
+		styles:         map[styleKey]*Style{},
+	}
+
+	for _, op := range layout.ops {
+		layout.previous = layout.current
+		layout.current = op
+		// layout.current = layout.popOperation()
+
+		switch e := layout.current.(type) {
+		case parser.ControlGroup:
+			switch e.GroupKind() {
+			case parser.ControlGroupKindBegin:
+				layout.pushFormatStackFrame()
+				layout.groupNodes = append(layout.groupNodes, layout.currentNode)
+			case parser.ControlGroupKindEnd:
+				layout.popFormatStackFrame()
+
+				// Return to the paragraph the group was opened in, which is
+				// the parent of any paragraph the group started
+				if last := len(layout.groupNodes) - 1; last >= 0 {
+					layout.currentNode = layout.groupNodes[last]
+					layout.groupNodes = layout.groupNodes[:last]
+				} else {
+					layout.currentNode = nil
+				}
+
+				// The result of a field ends with its group
+				for len(layout.fields) > 0 && layout.fields[len(layout.fields)-1].depth > len(layout.groupNodes) {
+					layout.fields = layout.fields[:len(layout.fields)-1]
+				}
+			}
+		case parser.FontTable:
+			for _, fnt := range e.Fonts() {
+				layout.storeFont(fnt.(parser.FontTableEntry))
+			}
+		case parser.ColorTable:
+			for _, clr := range e.Colors() {
+				layout.storeColor(clr.(parser.ColorTableEntry))
+			}
+		case parser.TableRow:
+			layout.rowDefinitions[max(layout.tableDepth, 1)] = e
+		case parser.TextFormat:
+			layout.processFormat(e)
+		case parser.StyleSheet:
+			layout.storeStyles(e)
+		case parser.ListTable:
+			for _, list := range e.Lists() {
+				layout.lists[list.(parser.List).ID()] = list.(parser.List)
+			}
+		case parser.ListOverrideTable:
+			for _, override := range e.Overrides() {
+				o := override.(parser.ListOverride)
+				layout.listOverrides[o.Index()] = o.ListID()
+			}
+		case parser.ParagraphNumbering:
+			if layout.currentNode != nil {
+				layout.currentNode.legacyList = paragraphNumberingStyle(e)
+			}
+		case parser.Field:
+			layout.fields = append(layout.fields, fieldState{field: e, depth: len(layout.groupNodes)})
+		case parser.Picture:
+			if layout.tableDepth > 0 {
+				layout.enterCellParagraph()
+			}
+			if layout.currentNode != nil {
+				layout.appendImage(e)
+			}
+		case parser.Text:
+			if layout.tableDepth > 0 {
+				layout.enterCellParagraph()
+			}
+			if layout.currentNode != nil {
+				layout.appendText(e)
+			}
+		default:
+		}
+	}
+
+	return layout.groupLists(layout.roots, nil)
+}
+
+func (layout *Layout) pushFormat(format FormatOp) {
+	layout.formatStack = append(layout.formatStack, format)
+}
+
+func (layout *Layout) pushFormatStackFrame() {
+	layout.formatStackFrames = append(layout.formatStackFrames, len(layout.formatStack))
+}
+
+func (layout *Layout) popFormatStackFrame() {
+	if len(layout.formatStackFrames) == 0 {
+		return
+	}
+
+	last := len(layout.formatStackFrames) - 1
+	stackIdx := layout.formatStackFrames[last]
+	layout.formatStackFrames = layout.formatStackFrames[:last]
+	layout.formatStack = layout.formatStack[:stackIdx]
+}
+
+func (layout *Layout) clearFormatStack() {
+	layout.formatStack = layout.formatStack[:0]
+	layout.formatStackFrames = layout.formatStackFrames[:0]
+}
+
+func (layout *Layout) storeFont(f parser.FontTableEntry) {
+	layout.fontTable[f.Index()] = Font{
+		Name: f.FontName().String(),
+	}
+}
+
+func (layout *Layout) storeColor(c parser.ColorTableEntry) {
+	clr := Color{}
+	channels := c.Channels()
+
+	clr.R = channels[0].(parser.ColorComponent).Value()
+	clr.G = channels[1].(parser.ColorComponent).Value()
+	clr.B = channels[2].(parser.ColorComponent).Value()
+
+	if channels[3] != nil {
+		clr.A = channels[3].(parser.ColorComponent).Value()
+	} else {
+		clr.A = 255
+	}
+
+	layout.colorTable = append(layout.colorTable, clr)
+}
+
+func (layout *Layout) processFormat(t parser.TextFormat) {
+	switch t.FormatKind() {
+	case parser.TextFormatColor:
+		if clr, exist := layout.lookupTextColor(t.Arg()); exist {
+			layout.pushFormat(clr)
+		}
+	case parser.TextFormatItalic:
+		layout.pushFormat(makeTextStyle(TextStyleItalic, t.Arg() != 0))
+	case parser.TextFormatStrike:
+		layout.pushFormat(makeTextStyle(TextStyleStrike, t.Arg() != 0))
+	case parser.TextFormatUnderline:
+		layout.pushUnderline(UnderlineStyleSolid, t.Arg() != 0)
+	case parser.TextFormatUnderlineDotted:
+		layout.pushUnderline(UnderlineStyleDotted, t.Arg() != 0)
+	case parser.TextFormatUnderlineDashed:
+		layout.pushUnderline(UnderlineStyleDashed, t.Arg() != 0)
+	case parser.TextFormatUnderlineDouble:
+		layout.pushUnderline(UnderlineStyleDouble, t.Arg() != 0)
+	case parser.TextFormatUnderlineWave:
+		layout.pushUnderline(UnderlineStyleWavy, t.Arg() != 0)
+	case parser.TextFormatUnderlineWords:
+		layout.pushUnderline(UnderlineStyleWords, t.Arg() != 0)
+	case parser.TextFormatUnderlineNone:
+		layout.pushUnderline(UnderlineStyleSolid, false)
+	case parser.TextFormatUnderlineColor:
+		if clr, exist := layout.lookupTextColor(t.Arg()); exist {
+			layout.pushFormat(UnderlineColor(clr))
+		}
+	case parser.TextFormatFontIndex:
+		// An unknown or unnamed font leaves the outer one in place
+		if font, exist := layout.fontTable[t.Arg()]; exist && font.Name != "" {
+			layout.pushFormat(font)
+		}
+	case parser.TextFormatFontSize:
+		layout.pushFormat(FontSize(t.Arg()))
+	case parser.TextFormatFontWeightBold:
+		if t.Arg() != 0 {
+			layout.pushFormat(FontWeightBold)
+		} else {
+			layout.pushFormat(FontWeightNormal)
+		}
+	case parser.TextFormatSuperscript:
+		layout.pushFormat(VerticalAlign{Kind: VerticalAlignSuperscript})
+	case parser.TextFormatSubscript:
+		layout.pushFormat(VerticalAlign{Kind: VerticalAlignSubscript})
+	case parser.TextFormatNoSuperSub:
+		layout.pushFormat(VerticalAlign{Kind: VerticalAlignBaseline})
+	case parser.TextFormatBaselineUp:
+		layout.pushBaselineOffset(baselineOffsetArg(t))
+	case parser.TextFormatBaselineDown:
+		layout.pushBaselineOffset(-baselineOffsetArg(t))
+	case parser.TextFormatHighlight:
+		clr, exist := layout.lookupHighlight(t.Arg())
+		layout.pushFormat(Highlight{Color: clr, None: !exist})
+	case parser.TextFormatBackgroundColor:
+		clr, exist := layout.lookupColor(t.Arg())
+		layout.pushFormat(BackgroundColor{Color: clr, None: !exist})
+	case parser.TextFormatParagraphBackgroundColor:
+		clr, exist := layout.lookupColor(t.Arg())
+		layout.pushFormat(ParagraphBackgroundColor{Color: clr, None: !exist})
+	case parser.TextFormatPlain:
+		layout.pushFormat(characterReset{})
+	case parser.TextFormatAlignCenter:
+		layout.pushFormat(TextAlignCenter)
+	case parser.TextFormatAlignJustify:
+		layout.pushFormat(TextAlignJustify)
+	case parser.TextFormatAlignRight:
+		layout.pushFormat(TextAlignRight)
+	case parser.TextFormatLeftIndent:
+		layout.pushFormat(TextIndent{
+			Dir:   -1,
+			Unit:  MeasuringUnitTwip,
+			Value: t.Arg(),
+			set:   textIndentValueSet,
+		})
+	case parser.TextFormatFirstIndent:
+		layout.pushFormat(TextIndent{
+			Dir:             -1,
+			Unit:            MeasuringUnitTwip,
+			FirstLineOffset: t.Arg(),
+			set:             textIndentFirstLineSet,
+		})
+	case parser.TextFormatSpaceBefore:
+		layout.pushFormat(SpaceBefore{makeSpacing(t.Arg())})
+	case parser.TextFormatSpaceAfter:
+		layout.pushFormat(SpaceAfter{makeSpacing(t.Arg())})
+	case parser.TextFormatSpaceBeforeAuto:
+		layout.pushFormat(SpaceBefore{makeSpacingAuto(t.Arg() != 0)})
+	case parser.TextFormatSpaceAfterAuto:
+		layout.pushFormat(SpaceAfter{makeSpacingAuto(t.Arg() != 0)})
+	case parser.TextFormatLineSpacing:
+		layout.pushFormat(makeLineSpacing(t.Arg()))
+	case parser.TextFormatLineSpacingMultiple:
+		layout.pushFormat(makeLineSpacingMultiple(t.Arg() != 0))
+	case parser.TextFormatTabAlignCenter:
+		layout.pendingTabStop.Align = TabAlignCenter
+	case parser.TextFormatTabAlignRight:
+		layout.pendingTabStop.Align = TabAlignRight
+	case parser.TextFormatTabAlignDecimal:
+		layout.pendingTabStop.Align = TabAlignDecimal
+	case parser.TextFormatTabLeaderDot:
+		layout.pendingTabStop.Leader = TabLeaderDot
+	case parser.TextFormatTabLeaderMiddleDot:
+		layout.pendingTabStop.Leader = TabLeaderMiddleDot
+	case parser.TextFormatTabLeaderHyphen:
+		layout.pendingTabStop.Leader = TabLeaderHyphen
+	case parser.TextFormatTabLeaderUnderline:
+		layout.pendingTabStop.Leader = TabLeaderUnderline
+	case parser.TextFormatTabLeaderThick:
+		layout.pendingTabStop.Leader = TabLeaderThick
+	case parser.TextFormatTabLeaderEqual:
+		layout.pendingTabStop.Leader = TabLeaderEqual
+	case parser.TextFormatTabPosition:
+		stop := layout.pendingTabStop
+		stop.Position = t.Arg()
+		layout.pendingTabStop = TabStop{}
+		layout.pushFormat(makeTabStops(stop))
+	case parser.TextFormatBarTabPosition:
+		// Bar tabs draw a vertical line rather than stopping text
+		layout.pendingTabStop = TabStop{}
+	case parser.TextFormatTab:
+		if layout.tableDepth > 0 {
+			layout.enterCellParagraph()
+		}
+		if layout.currentNode != nil {
+			layout.appendTab()
+		}
+	case parser.TextFormatLineBreak:
+		if layout.tableDepth > 0 {
+			layout.enterCellParagraph()
+		}
+		if layout.currentNode != nil {
+			parent, children := layout.inlineParent()
+			*children = append(*children, &LayoutLineBreak{parent: parent})
+		}
+
+	case parser.TextFormatParagraphClear:
+		if layout.currentNode == nil {
+			layout.clearFormatStack()
+		} else if layout.currentNode.ended && layout.currentNode.frameDepth == len(layout.formatStackFrames) {
+			// The properties of the previous paragraph do not carry over to
+			// its sibling
+			layout.popFormatStackFrame()
+		}
+		layout.pushFormatStackFrame()
+		p := &LayoutParagraph{
+			parent:     layout.paragraphParent(),
+			frameDepth: len(layout.formatStackFrames),
+		}
+
+		layout.appendChild(p.parent, p)
+		layout.currentNode = p
+		layout.pendingParagraph = p
+		layout.tableDepth = 0
+
+	case parser.TextFormatParagraphEnd:
+		if layout.currentNode != nil {
+			layout.currentNode.format = layout.buildFormat().paragraphFormat()
+			layout.currentNode.style = layout.currentStyle(false)
+			layout.currentNode.ended = true
+		}
+
+	case parser.TextFormatInTable:
+		layout.enterTable(max(layout.tableDepth, 1))
+	case parser.TextFormatTableDepth:
+		if t.Arg() > 0 {
+			layout.enterTable(t.Arg())
+		} else {
+			layout.tableDepth = 0
+		}
+	case parser.TextFormatParagraphStyle:
+		layout.pushStyle(parser.StyleKindParagraph, t.Arg())
+	case parser.TextFormatCharacterStyle:
+		layout.pushStyle(parser.StyleKindCharacter, t.Arg())
+	case parser.TextFormatListIndex:
+		if layout.currentNode != nil {
+			layout.currentNode.listIndex = t.Arg()
+		}
+	case parser.TextFormatListLevel:
+		if layout.currentNode != nil {
+			layout.currentNode.listLevel = t.Arg()
+		}
+	case parser.TextFormatCellEnd:
+		layout.endCell(1)
+	case parser.TextFormatNestedCellEnd:
+		layout.endCell(max(layout.tableDepth, 2))
+	case parser.TextFormatRowEnd:
+		layout.endRow(1)
+	case parser.TextFormatNestedRowEnd:
+		layout.endRow(max(layout.tableDepth, 2))
+	}
+}
+
+// lookupColor resolves an index of the color table. Index 0 is the automatic
+// color, the table only holds the colors defined after it.
+func (layout *Layout) lookupColor(index int) (Color, bool) {
+	if index <= 0 || index > len(layout.colorTable) {
+		return Color{}, false
+	}
+
+	return layout.colorTable[index-1], true
+}
+
+// lookupTextColor resolves the color of \cf or \ulc, index 0 being the
+// automatic color that ends the color of outer groups.
+func (layout *Layout) lookupTextColor(index int) (Color, bool) {
+	if index == 0 {
+		return Color{Auto: true}, true
+	}
+
+	return layout.lookupColor(index)
+}
+
+// lookupHighlight resolves the color of \highlight. The index refers to the
+// color table, but writers predating that rule use the fixed palette of Word
+// highlighters, so indices the table does not define fall back to it.
+func (layout *Layout) lookupHighlight(index int) (Color, bool) {
+	if clr, exist := layout.lookupColor(index); exist {
+		return clr, true
+	}
+
+	if index <= 0 || index > len(highlightPalette) {
+		return Color{}, false
+	}
+
+	return highlightPalette[index-1], true
+}
+
+func (layout *Layout) pushUnderline(style UnderlineStyle, on bool) {
+	layout.pushFormat(makeTextStyle(TextStyleUnderline, on))
+	if on {
+		layout.pushFormat(style)
+	}
+}
+
+// pushBaselineOffset raises the text by offset half-points, or lowers it when
+// negative.
+func (layout *Layout) pushBaselineOffset(offset int) {
+	if offset == 0 {
+		layout.pushFormat(VerticalAlign{Kind: VerticalAlignBaseline})
+		return
+	}
+
+	layout.pushFormat(VerticalAlign{Kind: VerticalAlignOffset, Offset: offset})
+}
+
+// baselineOffsetArg returns the offset of \up and \dn, which default to 3pt
+// when given no parameter.
+func baselineOffsetArg(t parser.TextFormat) int {
+	if t.Arg() == -1 {
+		return defaultBaselineOffset
+	}
+	return t.Arg()
+}
+
+func (layout *Layout) buildFormat() Format {
+	format := Format{}
+	plain := false
+
+	apply := func(f FormatOp) {
+		k := f.kind()
+
+		// Character formats pushed before a \plain no longer apply
+		if plain && !paragraphFormatKinds[k] {
+			return
+		}
+
+		if format[k] != nil {
+			if checkLayoutFormatOpConcat(format[k]) {
+				format[k] = format[k].concat(f)
+			}
+			return
+		}
+
+		format[k] = f
+	}
+
+	// Walk the format stack backward and skip any format that is already set in the bitmask
+	for i := len(layout.formatStack) - 1; i >= 0; i -= 1 {
+		switch f := layout.formatStack[i].(type) {
+		case characterReset:
+			plain = true
+		case styleReference:
+			// The formats of a style sit below the ones applied after it
+			for j := len(f.style.ops) - 1; j >= 0; j -= 1 {
+				apply(f.style.ops[j])
+			}
+		default:
+			apply(f)
+		}
+	}
+
+	// The underline style and color only matter while underlining
+	if style, _ := format[FormatTextStyle].(TextStyle); !style.Has(TextStyleUnderline) {
+		format[FormatUnderlineStyle] = nil
+		format[FormatUnderlineColor] = nil
+	}
+
+	for k, f := range format {
+		if f != nil && isDefaultFormat(f) {
+			format[k] = nil
+		}
+	}
+
+	return format
+}
+
+// appendText adds a run of text to the current paragraph with the character
+// format in effect. The run is merged into the previous one when both share
+// the same format and character style.
+func (layout *Layout) appendText(t parser.Text) {
+	format := layout.buildFormat().characterFormat()
+	style := layout.currentStyle(true)
+	parent, children := layout.inlineParent()
+
+	if len(*children) > 0 {
+		if last, ok := (*children)[len(*children)-1].(*LayoutText); ok && last.format == format && last.style == style {
+			if last != layout.lastText {
+				layout.lastText = last
+				layout.lastTextValue = strings.Builder{}
+				layout.lastTextValue.WriteString(last.value)
+			}
+			layout.lastTextValue.WriteString(t.String())
+			last.value = layout.lastTextValue.String()
+			return
+		}
+	}
+
+	*children = append(*children, &LayoutText{
+		format: format,
+		parent: parent,
+		style:  style,
+		value:  t.String(),
+	})
+}
+
+// inlineParent returns the node text and images go to: the current paragraph,
+// or the link of the hyperlink field being laid out in it.
+func (layout *Layout) inlineParent() (LayoutNode, *[]LayoutNode) {
+	last := len(layout.fields) - 1
+	if last < 0 || layout.fields[last].field.FieldKind() != parser.FieldHyperlink {
+		return layout.currentNode, &layout.currentNode.children
+	}
+
+	state := &layout.fields[last]
+	if state.link == nil || state.link.parent != LayoutNode(layout.currentNode) {
+		state.link = &LayoutLink{
+			parent: layout.currentNode,
+			href:   hyperlinkHref(state.field),
+		}
+		state.link.title, _ = state.field.Switch("o")
+		layout.currentNode.children = append(layout.currentNode.children, state.link)
+	}
+
+	return state.link, &state.link.children
+}
+
+// hyperlinkHref returns the target of a HYPERLINK field, where \l names an
+// anchor within the target document.
+func hyperlinkHref(f parser.Field) string {
+	href := f.Argument()
+	if anchor, exist := f.Switch("l"); exist {
+		href += "#" + anchor
+	}
+	return href
+}
+
+// appendTab adds a tab character to the current paragraph
+func (layout *Layout) appendTab() {
+	parent, children := layout.inlineParent()
+	*children = append(*children, &LayoutTab{
+		format: layout.buildFormat().characterFormat(),
+		parent: parent,
+	})
+}
+
+// appendImage adds a picture to the current paragraph. Pictures in a format
+// that cannot be shown are left out.
+func (layout *Layout) appendImage(p parser.Picture) {
+	imageFormat, exist := imageFormatLookup[p.FormatKind()]
+	if !exist || len(p.Data()) == 0 {
+		return
+	}
+
+	width, height := pictureSize(p)
+	parent, children := layout.inlineParent()
+	*children = append(*children, &LayoutImage{
+		format:      layout.buildFormat().characterFormat(),
+		parent:      parent,
+		imageFormat: imageFormat,
+		data:        p.Data(),
+		width:       width,
+		height:      height,
+	})
+}
+
+// pictureSize returns the displayed size of a picture in twips: the goal size
+// if any, otherwise the native one, scaled.
+func pictureSize(p parser.Picture) (width int, height int) {
+	width, height = p.GoalWidth(), p.GoalHeight()
+
+	if width == 0 || height == 0 {
+		switch p.FormatKind() {
+		case parser.PictureFormatEMF, parser.PictureFormatWMF:
+			// Metafiles are measured in hundredths of a millimeter
+			width = p.Width() * 1440 / 2540
+			height = p.Height() * 1440 / 2540
+		default:
+			width = p.Width() * twipsPerPixel
+			height = p.Height() * twipsPerPixel
+		}
+	}
+
+	return width * p.ScaleX() / 100, height * p.ScaleY() / 100
+}
@@ -0,0 +1,1215 @@
+package layout
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	LayoutNodeInvalid LayoutNodeKind = iota
+	LayoutNodeParagraph
+	LayoutNodeText
+	LayoutNodeTable
+	LayoutNodeTableRow
+	LayoutNodeTableCell
+	LayoutNodeImage
+	LayoutNodeLink
+	LayoutNodeList
+	LayoutNodeListItem
+	LayoutNodeTab
+	LayoutNodeLineBreak
+)
+
+type (
+	LayoutNodeKind int
+
+	LayoutNode interface {
+		Kind() LayoutNodeKind
+		Format() Format
+		Parent() LayoutNode
+	}
+
+	LayoutParagraph struct {
+		format   Format
+		parent   LayoutNode
+		children []LayoutNode
+		// Set once \par or \cell ended the paragraph
+		ended bool
+		// Number of format stack frames once the paragraph pushed its own
+		frameDepth int
+		// List membership, from \lsN and \ilvlN or from a Word 6 \pn
+		listIndex  int
+		listLevel  int
+		legacyList *listStyle
+		// Paragraph style in effect when the paragraph ended, nil if none
+		style *Style
+	}
+
+	LayoutText struct {
+		format Format
+		parent LayoutNode
+		// Character style of the run, nil if none
+		style *Style
+		value string
+	}
+
+	// Style is a paragraph or character style of the stylesheet. Its format
+	// includes the formats inherited from the styles it is based on.
+	Style struct {
+		Name   string
+		Format Format
+		ops    []FormatOp
+	}
+
+	// LayoutTab is a tab character, moving the text after it to the next tab
+	// stop of its paragraph
+	LayoutTab struct {
+		format Format
+		parent LayoutNode
+	}
+
+	// LayoutLineBreak starts a new line within a paragraph
+	LayoutLineBreak struct {
+		parent LayoutNode
+	}
+
+	// LayoutImage is a picture placed inline in a paragraph. The size is the
+	// displayed one, in twips.
+	LayoutImage struct {
+		format      Format
+		parent      LayoutNode
+		imageFormat ImageFormat
+		data        []byte
+		width       int
+		height      int
+	}
+
+	// LayoutLink holds the text and images of a hyperlink within a paragraph
+	LayoutLink struct {
+		parent   LayoutNode
+		children []LayoutNode
+		href     string
+		title    string
+	}
+
+	// LayoutList is a run of list paragraphs at one nesting level. Deeper
+	// levels are nested in the last item before them.
+	LayoutList struct {
+		parent    LayoutNode
+		items     []*LayoutListItem
+		styleType ListStyleType
+		start     int
+		level     int
+		key       listKey
+	}
+
+	LayoutListItem struct {
+		parent   *LayoutList
+		children []LayoutNode
+	}
+
+	LayoutTable struct {
+		parent LayoutNode
+		rows   []*LayoutTableRow
+		align  TableAlign
+		// Offset of the left edge from the margin, in twips
+		left int
+	}
+
+	LayoutTableRow struct {
+		parent *LayoutTable
+		cells  []*LayoutTableCell
+		// Minimum height in twips, or exact height when negative
+		height int
+		header bool
+	}
+
+	// LayoutTableCell holds the paragraphs and nested tables of a cell. Cells
+	// covered by the span of a merged neighbour are kept with Merged set so
+	// that rows keep one cell per column.
+	LayoutTableCell struct {
+		parent        *LayoutTableRow
+		children      []LayoutNode
+		width         int
+		borders       [BorderSideMAX]Border
+		background    *Color
+		verticalAlign CellAlign
+		colSpan       int
+		rowSpan       int
+		merged        bool
+	}
+)
+
+func (p *LayoutParagraph) Kind() LayoutNodeKind {
+	return LayoutNodeParagraph
+}
+
+func (p *LayoutParagraph) Format() Format {
+	return p.format
+}
+
+func (p *LayoutParagraph) Parent() LayoutNode {
+	return p.parent
+}
+
+func (t *LayoutText) Kind() LayoutNodeKind {
+	return LayoutNodeText
+}
+
+func (t *LayoutText) Format() Format {
+	return t.format
+}
+
+func (t *LayoutText) Parent() LayoutNode {
+	return t.parent
+}
+
+func (t *LayoutTab) Kind() LayoutNodeKind {
+	return LayoutNodeTab
+}
+
+func (t *LayoutTab) Format() Format {
+	return t.format
+}
+
+func (t *LayoutTab) Parent() LayoutNode {
+	return t.parent
+}
+
+func (b *LayoutLineBreak) Kind() LayoutNodeKind {
+	return LayoutNodeLineBreak
+}
+
+func (b *LayoutLineBreak) Format() Format {
+	return Format{}
+}
+
+func (b *LayoutLineBreak) Parent() LayoutNode {
+	return b.parent
+}
+
+func (i *LayoutImage) Kind() LayoutNodeKind {
+	return LayoutNodeImage
+}
+
+func (i *LayoutImage) Format() Format {
+	return i.format
+}
+
+func (i *LayoutImage) Parent() LayoutNode {
+	return i.parent
+}
+
+func (l *LayoutLink) Kind() LayoutNodeKind {
+	return LayoutNodeLink
+}
+
+func (l *LayoutLink) Format() Format {
+	return Format{}
+}
+
+func (l *LayoutLink) Parent() LayoutNode {
+	return l.parent
+}
+
+func (l *LayoutList) Kind() LayoutNodeKind {
+	return LayoutNodeList
+}
+
+func (l *LayoutList) Format() Format {
+	return Format{}
+}
+
+func (l *LayoutList) Parent() LayoutNode {
+	return l.parent
+}
+
+func (i *LayoutListItem) Kind() LayoutNodeKind {
+	return LayoutNodeListItem
+}
+
+func (i *LayoutListItem) Format() Format {
+	return Format{}
+}
+
+func (i *LayoutListItem) Parent() LayoutNode {
+	return i.parent
+}
+
+func (t *LayoutTable) Kind() LayoutNodeKind {
+	return LayoutNodeTable
+}
+
+func (t *LayoutTable) Format() Format {
+	return Format{}
+}
+
+func (t *LayoutTable) Parent() LayoutNode {
+	return t.parent
+}
+
+func (r *LayoutTableRow) Kind() LayoutNodeKind {
+	return LayoutNodeTableRow
+}
+
+func (r *LayoutTableRow) Format() Format {
+	return Format{}
+}
+
+func (r *LayoutTableRow) Parent() LayoutNode {
+	return r.parent
+}
+
+func (c *LayoutTableCell) Kind() LayoutNodeKind {
+	return LayoutNodeTableCell
+}
+
+func (c *LayoutTableCell) Format() Format {
+	return Format{}
+}
+
+func (c *LayoutTableCell) Parent() LayoutNode {
+	return c.parent
+}
+
+func (p *LayoutParagraph) Children() []LayoutNode {
+	return p.children
+}
+
+// Style returns the paragraph style of the paragraph, nil if none
+func (p *LayoutParagraph) Style() *Style {
+	return p.style
+}
+
+func (t *LayoutText) Value() string {
+	return t.value
+}
+
+// Style returns the character style of the run, nil if none
+func (t *LayoutText) Style() *Style {
+	return t.style
+}
+
+func (i *LayoutImage) ImageFormat() ImageFormat {
+	return i.imageFormat
+}
+
+func (i *LayoutImage) Data() []byte {
+	return i.data
+}
+
+// Width returns the displayed width in twips, 0 when unknown
+func (i *LayoutImage) Width() int {
+	return i.width
+}
+
+// Height returns the displayed height in twips, 0 when unknown
+func (i *LayoutImage) Height() int {
+	return i.height
+}
+
+func (l *LayoutLink) Children() []LayoutNode {
+	return l.children
+}
+
+// Href returns the target of the link: a URL, a "#anchor" within the document
+// or both.
+func (l *LayoutLink) Href() string {
+	return l.href
+}
+
+// Title returns the tooltip of the link, empty if none
+func (l *LayoutLink) Title() string {
+	return l.title
+}
+
+func (l *LayoutList) Items() []*LayoutListItem {
+	return l.items
+}
+
+func (l *LayoutList) StyleType() ListStyleType {
+	return l.styleType
+}
+
+// Ordered reports whether the items are numbered rather than bulleted
+func (l *LayoutList) Ordered() bool {
+	return l.styleType.Ordered()
+}
+
+// Start returns the number of the first item
+func (l *LayoutList) Start() int {
+	return l.start
+}
+
+// Level returns the nesting level of the list, starting at 0
+func (l *LayoutList) Level() int {
+	return l.level
+}
+
+func (i *LayoutListItem) Children() []LayoutNode {
+	return i.children
+}
+
+func (t *LayoutTable) Rows() []*LayoutTableRow {
+	return t.rows
+}
+
+func (t *LayoutTable) Align() TableAlign {
+	return t.align
+}
+
+// Left returns the offset of the table from the left margin in twips
+func (t *LayoutTable) Left() int {
+	return t.left
+}
+
+func (r *LayoutTableRow) Cells() []*LayoutTableCell {
+	return r.cells
+}
+
+// Height returns the minimum height of the row in twips, the exact height
+// when negative and 0 when the row fits its content.
+func (r *LayoutTableRow) Height() int {
+	return r.height
+}
+
+func (r *LayoutTableRow) Header() bool {
+	return r.header
+}
+
+func (c *LayoutTableCell) Children() []LayoutNode {
+	return c.children
+}
+
+// Width returns the width of the cell in twips, 0 when the row had no
+// definition for it.
+func (c *LayoutTableCell) Width() int {
+	return c.width
+}
+
+func (c *LayoutTableCell) Border(side BorderSide) Border {
+	return c.borders[side]
+}
+
+// Background returns the shading of the cell, nil if it has none
+func (c *LayoutTableCell) Background() *Color {
+	return c.background
+}
+
+func (c *LayoutTableCell) VerticalAlign() CellAlign {
+	return c.verticalAlign
+}
+
+func (c *LayoutTableCell) ColSpan() int {
+	return c.colSpan
+}
+
+func (c *LayoutTableCell) RowSpan() int {
+	return c.rowSpan
+}
+
+// Merged reports whether the cell is covered by a merged neighbour and has
+// no output of its own.
+func (c *LayoutTableCell) Merged() bool {
+	return c.merged
+}
+
+const (
+	ListStyleDecimal ListStyleType = iota
+	ListStyleUpperRoman
+	ListStyleLowerRoman
+	ListStyleUpperAlpha
+	ListStyleLowerAlpha
+	ListStyleDisc
+	ListStyleCircle
+	ListStyleSquare
+	ListStyleNone
+)
+
+var (
+	listStyleTypeStr = map[ListStyleType]string{
+		ListStyleDecimal:    "decimal",
+		ListStyleUpperRoman: "upper-roman",
+		ListStyleLowerRoman: "lower-roman",
+		ListStyleUpperAlpha: "upper-alpha",
+		ListStyleLowerAlpha: "lower-alpha",
+		ListStyleDisc:       "disc",
+		ListStyleCircle:     "circle",
+		ListStyleSquare:     "square",
+		ListStyleNone:       "none",
+	}
+)
+
+const (
+	TabAlignLeft TabAlign = iota
+	TabAlignCenter
+	TabAlignRight
+	// The decimal point of the text is aligned on the stop
+	TabAlignDecimal
+)
+
+const (
+	TabLeaderNone TabLeader = iota
+	TabLeaderDot
+	TabLeaderMiddleDot
+	TabLeaderHyphen
+	TabLeaderUnderline
+	TabLeaderThick
+	TabLeaderEqual
+)
+
+const (
+	ImageFormatPNG ImageFormat = iota
+	ImageFormatJPEG
+	ImageFormatEMF
+	ImageFormatWMF
+)
+
+var (
+	imageMIMETypeStr = map[ImageFormat]string{
+		ImageFormatPNG:  "image/png",
+		ImageFormatJPEG: "image/jpeg",
+		ImageFormatEMF:  "image/emf",
+		ImageFormatWMF:  "image/wmf",
+	}
+
+	imageExtensionStr = map[ImageFormat]string{
+		ImageFormatPNG:  "png",
+		ImageFormatJPEG: "jpg",
+		ImageFormatEMF:  "emf",
+		ImageFormatWMF:  "wmf",
+	}
+)
+
+const (
+	TableAlignLeft TableAlign = iota
+	TableAlignCenter
+	TableAlignRight
+)
+
+const (
+	CellAlignTop CellAlign = iota
+	CellAlignMiddle
+	CellAlignBottom
+)
+
+const (
+	BorderSideTop BorderSide = iota
+	BorderSideLeft
+	BorderSideBottom
+	BorderSideRight
+	BorderSideMAX
+)
+
+const (
+	BorderStyleNone BorderStyle = iota
+	BorderStyleSolid
+	BorderStyleDouble
+	BorderStyleDotted
+	BorderStyleDashed
+)
+
+var (
+	cellAlignStr = map[CellAlign]string{
+		CellAlignTop:    "top",
+		CellAlignMiddle: "middle",
+		CellAlignBottom: "bottom",
+	}
+
+	borderStyleStr = map[BorderStyle]string{
+		BorderStyleNone:   "none",
+		BorderStyleSolid:  "solid",
+		BorderStyleDouble: "double",
+		BorderStyleDotted: "dotted",
+		BorderStyleDashed: "dashed",
+	}
+)
+
+type (
+	ListStyleType int
+
+	TabAlign int
+
+	// TabLeader is the line filling the space before a tab stop
+	TabLeader int
+
+	// TabStop is a tab stop of a paragraph, at Position twips from the left
+	// margin
+	TabStop struct {
+		Position int
+		Align    TabAlign
+		Leader   TabLeader
+	}
+
+	ImageFormat int
+
+	TableAlign int
+
+	CellAlign int
+
+	BorderSide int
+
+	BorderStyle int
+
+	// Border is one side of the border of a table cell
+	Border struct {
+		Style BorderStyle
+		// Width in twips
+		Width int
+		Color Color
+		// AutoColor is set when the border uses the text color
+		AutoColor bool
+	}
+)
+
+func (t ListStyleType) String() string {
+	return listStyleTypeStr[t]
+}
+
+func (t ListStyleType) Ordered() bool {
+	return t <= ListStyleLowerAlpha
+}
+
+func (f ImageFormat) MIMEType() string {
+	return imageMIMETypeStr[f]
+}
+
+// Extension returns the usual file name extension of the format, without the
+// leading dot.
+func (f ImageFormat) Extension() string {
+	return imageExtensionStr[f]
+}
+
+func (a CellAlign) String() string {
+	return cellAlignStr[a]
+}
+
+func (s BorderStyle) String() string {
+	return borderStyleStr[s]
+}
+
+const (
+	FormatColor FormatKind = iota
+	FormatTextStyle
+	FormatFont
+	FormatFontSize
+	FormatFontWeight
+	FormatTextAlign
+	FormatTextIndent
+	FormatUnderlineStyle
+	FormatUnderlineColor
+	FormatVerticalAlign
+	FormatHighlight
+	FormatBackgroundColor
+	FormatParagraphBackgroundColor
+	FormatSpaceBefore
+	FormatSpaceAfter
+	FormatLineHeight
+	FormatTabStops
+	FormatMAX
+)
+
+const (
+	TextStyleItalic TextStyleKind = iota
+	TextStyleStrike
+	TextStyleUnderline
+	TextStyleMAX
+)
+
+const (
+	FontWeightNormal FontWeight = iota
+	FontWeightBold
+)
+
+const (
+	UnderlineStyleSolid UnderlineStyle = iota
+	UnderlineStyleDotted
+	UnderlineStyleDashed
+	UnderlineStyleDouble
+	UnderlineStyleWavy
+	// Underline words but not the spaces between them
+	UnderlineStyleWords
+)
+
+var (
+	underlineStyleStr = map[UnderlineStyle]string{
+		UnderlineStyleSolid:  "solid",
+		UnderlineStyleDotted: "dotted",
+		UnderlineStyleDashed: "dashed",
+		UnderlineStyleDouble: "double",
+		UnderlineStyleWavy:   "wavy",
+		UnderlineStyleWords:  "solid",
+	}
+)
+
+const (
+	VerticalAlignBaseline VerticalAlignKind = iota
+	VerticalAlignSuperscript
+	VerticalAlignSubscript
+	// Raised or lowered by an offset without changing the font size
+	VerticalAlignOffset
+)
+
+var (
+	fontWeightStr = map[FontWeight]string{
+		FontWeightNormal: "normal",
+		FontWeightBold:   "bold",
+	}
+)
+
+const (
+	TextAlignCenter TextAlign = iota
+	TextAlignJustify
+	TextAlignRight
+)
+
+var (
+	textAlignStr = map[TextAlign]string{
+		TextAlignCenter:  "center",
+		TextAlignJustify: "justify",
+		TextAlignRight:   "right",
+	}
+)
+
+type (
+	FormatKind int
+
+	Format [FormatMAX]FormatOp
+
+	FormatOp interface {
+		kind() FormatKind
+		concat(FormatOp) FormatOp
+	}
+
+	Font struct {
+		Name string
+	}
+
+	// Color is a color of the color table, or the automatic color of index
+	// 0 when Auto is set
+	Color struct {
+		R, G, B, A uint8
+		Auto       bool
+	}
+
+	TextStyleKind byte
+
+	// TextStyle holds a set of toggled styles. Styles in the set mask were
+	// explicitly turned on or off, the others are left to outer formats.
+	TextStyle struct {
+		set byte
+		on  byte
+	}
+
+	// FontSize is a font size in half-points, as given by \fs
+	FontSize int
+
+	UnderlineStyle int
+
+	UnderlineColor Color
+
+	// Highlight is the marker pen color of \highlight. None turns off the
+	// highlight of an outer format.
+	Highlight struct {
+		Color
+		None bool
+	}
+
+	// BackgroundColor is the shading behind a run of text
+	BackgroundColor struct {
+		Color
+		None bool
+	}
+
+	// ParagraphBackgroundColor is the shading behind a whole paragraph
+	ParagraphBackgroundColor struct {
+		Color
+		None bool
+	}
+
+	VerticalAlignKind int
+
+	VerticalAlign struct {
+		Kind VerticalAlignKind
+		// Offset of the baseline in half-points, positive when raised. Only
+		// used with VerticalAlignOffset.
+		Offset int
+	}
+
+	FontWeight int
+
+	TextAlign int
+
+	// TextIndent is the indentation of a paragraph, set by \li and \fi which
+	// may come from different formats. Value indents the whole paragraph,
+	// FirstLineOffset moves its first line from there.
+	TextIndent struct {
+		Dir             int
+		Unit            MeasuringUnit
+		Value           int
+		FirstLineOffset int
+		set             byte
+	}
+
+	// Spacing is the space around a paragraph, set by a value and an auto
+	// flag which may come from different formats. Value is in twips, Auto
+	// replaces it with the spacing Word picks by itself.
+	Spacing struct {
+		Value int
+		Auto  bool
+		set   byte
+	}
+
+	// SpaceBefore is the space above a paragraph, set by \sb and \sbauto
+	SpaceBefore struct {
+		Spacing
+	}
+
+	// SpaceAfter is the space below a paragraph, set by \sa and \saauto
+	SpaceAfter struct {
+		Spacing
+	}
+
+	// LineHeight is the line spacing of a paragraph, set by \sl and \slmult
+	// which may come from different formats. Value is in twips: the minimum
+	// height of a line when positive, its exact height when negative and
+	// single spacing when 0. With Multiple, a positive Value is in 240ths of
+	// single spacing instead.
+	LineHeight struct {
+		Value    int
+		Multiple bool
+		set      byte
+	}
+
+	// TabStops are the tab stops of a paragraph. Each \tx pushes one stop,
+	// the stops of a paragraph are gathered when its format is built. The
+	// stops are shared and never modified, which keeps formats comparable.
+	TabStops struct {
+		stops *[]TabStop
+	}
+)
+
+const (
+	lineHeightValueSet byte = 1 << iota
+	lineHeightMultipleSet
+)
+
+const (
+	textIndentValueSet byte = 1 << iota
+	textIndentFirstLineSet
+)
+
+const (
+	spacingValueSet byte = 1 << iota
+	spacingAutoSet
+)
+
+var (
+	// Formats that apply to a whole paragraph rather than to a run of text
+	paragraphFormatKinds = [FormatMAX]bool{
+		FormatTextAlign:                true,
+		FormatTextIndent:               true,
+		FormatParagraphBackgroundColor: true,
+		FormatSpaceBefore:              true,
+		FormatSpaceAfter:               true,
+		FormatLineHeight:               true,
+		FormatTabStops:                 true,
+	}
+)
+
+// IsParagraphFormatKind reports whether a kind of format applies to whole
+// paragraphs rather than to runs of text
+func IsParagraphFormatKind(k FormatKind) bool {
+	return paragraphFormatKinds[k]
+}
+
+// paragraphFormat returns a copy of the format keeping only the paragraph
+// level properties
+func (f Format) paragraphFormat() Format {
+	for k := range f {
+		if !paragraphFormatKinds[k] {
+			f[k] = nil
+		}
+	}
+	return f
+}
+
+// characterFormat returns a copy of the format keeping only the properties
+// that apply to a run of text
+func (f Format) characterFormat() Format {
+	for k := range f {
+		if paragraphFormatKinds[k] {
+			f[k] = nil
+		}
+	}
+	return f
+}
+
+// Merge returns a copy of the format with the properties of other applied
+// over it, the way a format pushed after another one would.
+func (f Format) Merge(other Format) Format {
+	for k, op := range other {
+		if op == nil {
+			continue
+		}
+		if f[k] != nil && checkLayoutFormatOpConcat(op) {
+			f[k] = op.concat(f[k])
+			continue
+		}
+		f[k] = op
+	}
+	return f
+}
+
+// isDefaultFormat reports whether a resolved format op leaves the property as
+// it would be without any formatting, in which case it does not need output.
+func isDefaultFormat(op FormatOp) bool {
+	switch f := op.(type) {
+	case Color:
+		return f.Auto
+	case UnderlineColor:
+		return f.Auto
+	case TextStyle:
+		return f.on == 0
+	case FontWeight:
+		return f == FontWeightNormal
+	case UnderlineStyle:
+		return f == UnderlineStyleSolid
+	case VerticalAlign:
+		return f.Kind == VerticalAlignBaseline
+	case Highlight:
+		return f.None
+	case BackgroundColor:
+		return f.None
+	case ParagraphBackgroundColor:
+		return f.None
+	case SpaceBefore:
+		return f.Twips() == 0
+	case SpaceAfter:
+		return f.Twips() == 0
+	case LineHeight:
+		return f.Value == 0
+	case TextIndent:
+		return f.Value == 0 && f.FirstLineOffset == 0
+	}
+	return false
+}
+
+func checkLayoutFormatOpConcat(op FormatOp) (ok bool) {
+	switch op.(type) {
+	// case Font:
+	// 	ok = false
+	// case Color:
+	// 	ok = false
+	// case FontSize:
+	// 	ok = false
+	// case FontWeight:
+	// 	ok = false
+	// case TextAlign:
+	// 	ok = false
+	case TextStyle:
+		ok = true
+	case TextIndent:
+		ok = true
+	case LineHeight:
+		ok = true
+	case SpaceBefore:
+		ok = true
+	case SpaceAfter:
+		ok = true
+	case TabStops:
+		ok = true
+	default:
+		ok = false
+	}
+	return ok
+}
+
+// characterReset marks the point of the format stack where \plain reset the
+// character formatting. It is not a property of its own.
+type characterReset struct{}
+
+func (r characterReset) kind() FormatKind {
+	return FormatMAX
+}
+
+func (r characterReset) concat(other FormatOp) FormatOp {
+	return r
+}
+
+func (f Font) kind() FormatKind {
+	return FormatFont
+}
+
+func (f Font) concat(other FormatOp) FormatOp {
+	return f
+}
+
+func (c Color) kind() FormatKind {
+	return FormatColor
+}
+
+func (c Color) concat(other FormatOp) FormatOp {
+	return c
+}
+
+func (s TextStyle) kind() FormatKind {
+	return FormatTextStyle
+}
+
+func (s TextStyle) concat(other FormatOp) FormatOp {
+	o := other.(TextStyle)
+	return TextStyle{
+		set: s.set | o.set,
+		on:  s.on | (o.on &^ s.set),
+	}
+}
+
+func (f FontSize) kind() FormatKind {
+	return FormatFontSize
+}
+
+func (f FontSize) concat(other FormatOp) FormatOp {
+	return f
+}
+
+func makeTextStyle(k TextStyleKind, on bool) TextStyle {
+	var mask byte = 1 << k
+	s := TextStyle{set: mask}
+	if on {
+		s.on = mask
+	}
+	return s
+}
+
+func (s TextStyle) Has(k TextStyleKind) bool {
+	var mask byte = 1 << k
+	return (s.on&mask)>>k == 1
+}
+
+func (u UnderlineStyle) String() string {
+	return underlineStyleStr[u]
+}
+
+func (u UnderlineStyle) kind() FormatKind {
+	return FormatUnderlineStyle
+}
+
+func (u UnderlineStyle) concat(other FormatOp) FormatOp {
+	return u
+}
+
+func (u UnderlineColor) kind() FormatKind {
+	return FormatUnderlineColor
+}
+
+func (u UnderlineColor) concat(other FormatOp) FormatOp {
+	return u
+}
+
+func (h Highlight) kind() FormatKind {
+	return FormatHighlight
+}
+
+func (h Highlight) concat(other FormatOp) FormatOp {
+	return h
+}
+
+func (b BackgroundColor) kind() FormatKind {
+	return FormatBackgroundColor
+}
+
+func (b BackgroundColor) concat(other FormatOp) FormatOp {
+	return b
+}
+
+func (b ParagraphBackgroundColor) kind() FormatKind {
+	return FormatParagraphBackgroundColor
+}
+
+func (b ParagraphBackgroundColor) concat(other FormatOp) FormatOp {
+	return b
+}
+
+func (v VerticalAlign) kind() FormatKind {
+	return FormatVerticalAlign
+}
+
+func (v VerticalAlign) concat(other FormatOp) FormatOp {
+	return v
+}
+
+func (f FontWeight) String() string {
+	return fontWeightStr[f]
+}
+
+func (f FontWeight) kind() FormatKind {
+	return FormatFontWeight
+}
+
+func (f FontWeight) concat(other FormatOp) FormatOp {
+	return f
+}
+
+func (a TextAlign) String() string {
+	return textAlignStr[a]
+}
+
+func (a TextAlign) kind() FormatKind {
+	return FormatTextAlign
+}
+
+func (a TextAlign) concat(other FormatOp) FormatOp {
+	return a
+}
+
+func (i TextIndent) kind() FormatKind {
+	return FormatTextIndent
+}
+
+// concat completes the indentation with the parts an outer format set and
+// this one did not
+func (i TextIndent) concat(other FormatOp) FormatOp {
+	o, ok := other.(TextIndent)
+	if !ok {
+		return i
+	}
+
+	if i.set&textIndentValueSet == 0 && o.set&textIndentValueSet != 0 {
+		i.Value = o.Value
+	}
+	if i.set&textIndentFirstLineSet == 0 && o.set&textIndentFirstLineSet != 0 {
+		i.FirstLineOffset = o.FirstLineOffset
+	}
+	i.set |= o.set
+
+	return i
+}
+
+func (s SpaceBefore) kind() FormatKind {
+	return FormatSpaceBefore
+}
+
+func (s SpaceBefore) concat(other FormatOp) FormatOp {
+	o, ok := other.(SpaceBefore)
+	if !ok {
+		return s
+	}
+	s.Spacing = s.complete(o.Spacing)
+	return s
+}
+
+func (s SpaceAfter) kind() FormatKind {
+	return FormatSpaceAfter
+}
+
+func (s SpaceAfter) concat(other FormatOp) FormatOp {
+	o, ok := other.(SpaceAfter)
+	if !ok {
+		return s
+	}
+	s.Spacing = s.complete(o.Spacing)
+	return s
+}
+
+func makeSpacing(value int) Spacing {
+	return Spacing{Value: value, set: spacingValueSet}
+}
+
+func makeSpacingAuto(auto bool) Spacing {
+	return Spacing{Auto: auto, set: spacingAutoSet}
+}
+
+// Twips returns the space in twips, the automatic one when Auto is set
+func (s Spacing) Twips() int {
+	if s.Auto {
+		return autoParagraphSpacing
+	}
+	return s.Value
+}
+
+// complete fills the spacing with the parts an outer format set and this one
+// did not
+func (s Spacing) complete(o Spacing) Spacing {
+	if s.set&spacingValueSet == 0 && o.set&spacingValueSet != 0 {
+		s.Value = o.Value
+	}
+	if s.set&spacingAutoSet == 0 && o.set&spacingAutoSet != 0 {
+		s.Auto = o.Auto
+	}
+	s.set |= o.set
+
+	return s
+}
+
+func makeLineSpacing(value int) LineHeight {
+	return LineHeight{Value: value, set: lineHeightValueSet}
+}
+
+func makeLineSpacingMultiple(multiple bool) LineHeight {
+	return LineHeight{Multiple: multiple, set: lineHeightMultipleSet}
+}
+
+func (l LineHeight) kind() FormatKind {
+	return FormatLineHeight
+}
+
+// concat completes the line height with the parts an outer format set and
+// this one did not
+func (l LineHeight) concat(other FormatOp) FormatOp {
+	o, ok := other.(LineHeight)
+	if !ok {
+		return l
+	}
+
+	if l.set&lineHeightValueSet == 0 && o.set&lineHeightValueSet != 0 {
+		l.Value = o.Value
+	}
+	if l.set&lineHeightMultipleSet == 0 && o.set&lineHeightMultipleSet != 0 {
+		l.Multiple = o.Multiple
+	}
+	l.set |= o.set
+
+	return l
+}
+
+func makeTabStops(stops ...TabStop) TabStops {
+	return TabStops{stops: &stops}
+}
+
+// Stops returns the tab stops ordered by position
+func (t TabStops) Stops() []TabStop {
+	if t.stops == nil {
+		return nil
+	}
+	return *t.stops
+}
+
+func (t TabStops) String() string {
+	return fmt.Sprint(t.Stops())
+}
+
+func (t TabStops) kind() FormatKind {
+	return FormatTabStops
+}
+
+// concat adds the stops of an outer format, a stop of this one replacing the
+// outer stop at the same position
+func (t TabStops) concat(other FormatOp) FormatOp {
+	o, ok := other.(TabStops)
+	if !ok {
+		return t
+	}
+
+	stops := slices.Clone(t.Stops())
+	for _, stop := range o.Stops() {
+		if !slices.ContainsFunc(stops, func(s TabStop) bool { return s.Position == stop.Position }) {
+			stops = append(stops, stop)
+		}
+	}
+	slices.SortFunc(stops, func(a TabStop, b TabStop) int {
+		return a.Position - b.Position
+	})
+
+	return makeTabStops(stops...)
+}
@@ -0,0 +1,105 @@
+package layout
+
+import (
+	"testing"
+
+	"rtf-parser/parser"
+)
+
+// buildTexts lays out a document and returns the text runs of its first
+// paragraph.
+func buildTexts(t *testing.T, input string) []*LayoutText {
+	t.Helper()
+
+	ops, err := parser.Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := BuildLayout(ops)
+	if len(roots) == 0 {
+		t.Fatalf("no paragraph laid out")
+	}
+
+	texts := []*LayoutText{}
+	for _, child := range roots[0].(*LayoutParagraph).Children() {
+		if text, ok := child.(*LayoutText); ok {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+func TestAutomaticColor(t *testing.T) {
+	red := Color{R: 255, A: 255}
+
+	texts := buildTexts(t, `{\rtf1\ansi{\colortbl;\red255\green0\blue0;}\pard\cf1 red {\cf0 auto} red\par}`)
+	if len(texts) != 3 {
+		t.Fatalf("got %d runs, want 3", len(texts))
+	}
+
+	for i, want := range []FormatOp{red, nil, red} {
+		if got := texts[i].Format()[FormatColor]; got != want {
+			t.Errorf("run %q: color %v, want %v", texts[i].Value(), got, want)
+		}
+	}
+}
+
+func TestAutomaticUnderlineColor(t *testing.T) {
+	texts := buildTexts(t, `{\rtf1\ansi{\colortbl;\red255\green0\blue0;}\pard\ul\ulc1 red {\ulc0 auto}\par}`)
+	if len(texts) != 2 {
+		t.Fatalf("got %d runs, want 2", len(texts))
+	}
+
+	if got := texts[1].Format()[FormatUnderlineColor]; got != nil {
+		t.Errorf("run %q: underline color %v, want none", texts[1].Value(), got)
+	}
+}
+
+func TestAutomaticSpacing(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		before int
+	}{
+		{name: "auto after value", input: `\sb100\sbauto1`, before: autoParagraphSpacing},
+		{name: "value after auto", input: `\sbauto1\sb100`, before: autoParagraphSpacing},
+		{name: "auto without parameter", input: `\sbauto\sb100`, before: autoParagraphSpacing},
+		{name: "auto turned off", input: `\sb100\sbauto1\sbauto0`, before: 100},
+		{name: "no value", input: `\sbauto0`, before: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := parser.Parse(`{\rtf1\ansi\pard` + test.input + ` a\par}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			format := BuildLayout(ops)[0].(*LayoutParagraph).Format()
+			before := 0
+			if space, ok := format[FormatSpaceBefore].(SpaceBefore); ok {
+				before = space.Twips()
+			}
+			if before != test.before {
+				t.Errorf("space before = %d, want %d", before, test.before)
+			}
+		})
+	}
+}
+
+func TestInheritedAutomaticSpacing(t *testing.T) {
+	// A style turning on automatic spacing is cancelled by \saauto0
+	ops, err := parser.Parse(`{\rtf1\ansi{\stylesheet{\s1\sa100\saauto1 Spaced;}}\pard\s1 a\par\pard\s1\saauto0 b\par}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := BuildLayout(ops)
+	for i, want := range []int{autoParagraphSpacing, 100} {
+		space, _ := roots[i].(*LayoutParagraph).Format()[FormatSpaceAfter].(SpaceAfter)
+		if space.Twips() != want {
+			t.Errorf("paragraph %d: space after = %d, want %d", i, space.Twips(), want)
+		}
+	}
+}
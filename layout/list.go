@@ -0,0 +1,201 @@
+package layout
+
+import (
+	"rtf-parser/parser"
+)
+
+const (
+	// Index of the lists made of Word 6 \pn paragraphs
+	legacyListIndex = -1
+)
+
+var (
+	listStyleTypeLookup = map[parser.ListNumberFormat]ListStyleType{
+		parser.ListNumberDecimal:    ListStyleDecimal,
+		parser.ListNumberUpperRoman: ListStyleUpperRoman,
+		parser.ListNumberLowerRoman: ListStyleLowerRoman,
+		parser.ListNumberUpperAlpha: ListStyleUpperAlpha,
+		parser.ListNumberLowerAlpha: ListStyleLowerAlpha,
+		parser.ListNumberBullet:     ListStyleDisc,
+		parser.ListNumberNone:       ListStyleNone,
+	}
+
+	// Bullet characters drawn as something else than a disc, including the
+	// private use code points of the Symbol and Wingdings fonts
+	bulletStyleLookup = map[rune]ListStyleType{
+		'o': ListStyleCircle,
+		'◦': ListStyleCircle,
+		'○': ListStyleCircle,
+		'▪': ListStyleSquare,
+		'■': ListStyleSquare,
+		'': ListStyleSquare,
+		'': ListStyleSquare,
+		'§': ListStyleSquare,
+	}
+)
+
+type (
+	listKey struct {
+		index     int
+		level     int
+		styleType ListStyleType
+	}
+
+	// listStyle is the list a paragraph belongs to
+	listStyle struct {
+		key       listKey
+		styleType ListStyleType
+		startAt   int
+	}
+)
+
+// paragraphNumberingStyle returns the list style of a Word 6 numbered
+// paragraph.
+func paragraphNumberingStyle(n parser.ParagraphNumbering) *listStyle {
+	styleType := resolveListStyleType(n.NumberFormat(), n.TextBefore())
+	return &listStyle{
+		key:       listKey{index: legacyListIndex, level: n.Level(), styleType: styleType},
+		styleType: styleType,
+		startAt:   n.StartAt(),
+	}
+}
+
+// resolveListStyleType returns the style of a list level, telling bullets
+// apart by the character drawn for them.
+func resolveListStyleType(format parser.ListNumberFormat, text string) ListStyleType {
+	styleType := listStyleTypeLookup[format]
+	if styleType != ListStyleDisc {
+		return styleType
+	}
+
+	for _, r := range text {
+		if bullet, exist := bulletStyleLookup[r]; exist {
+			return bullet
+		}
+		break
+	}
+
+	return styleType
+}
+
+// paragraphListStyle returns the list a paragraph is an item of, if any
+func (layout *Layout) paragraphListStyle(p *LayoutParagraph) (listStyle, bool) {
+	if p.listIndex > 0 {
+		style := listStyle{
+			key:       listKey{index: p.listIndex, level: p.listLevel},
+			styleType: ListStyleDecimal,
+			startAt:   1,
+		}
+
+		list, exist := layout.lists[layout.listOverrides[p.listIndex]]
+		if exist && p.listLevel >= 0 && p.listLevel < len(list.Levels()) {
+			level := list.Levels()[p.listLevel].(parser.ListLevel)
+			style.styleType = resolveListStyleType(level.NumberFormat(), level.Text())
+			style.startAt = level.StartAt()
+		}
+		style.key.styleType = style.styleType
+
+		return style, true
+	}
+
+	if p.legacyList != nil {
+		return *p.legacyList, true
+	}
+
+	return listStyle{}, false
+}
+
+// groupLists replaces the runs of list paragraphs among nodes by lists, each
+// paragraph becoming an item. Lists of deeper levels are nested in the item
+// before them. Table cells are grouped as well.
+func (layout *Layout) groupLists(nodes []LayoutNode, parent LayoutNode) []LayoutNode {
+	result := make([]LayoutNode, 0, len(nodes))
+	// Open lists, outermost first
+	open := []*LayoutList{}
+
+	for _, node := range nodes {
+		if table, ok := node.(*LayoutTable); ok {
+			for _, row := range table.rows {
+				for _, cell := range row.cells {
+					cell.children = layout.groupLists(cell.children, cell)
+				}
+			}
+		}
+
+		p, ok := node.(*LayoutParagraph)
+		if !ok {
+			open = open[:0]
+			result = append(result, node)
+			continue
+		}
+
+		style, isItem := layout.paragraphListStyle(p)
+		if !isItem {
+			open = open[:0]
+			result = append(result, node)
+			continue
+		}
+
+		level := style.key.level
+		for len(open) > 0 && open[len(open)-1].level > level {
+			open = open[:len(open)-1]
+		}
+
+		// Another list at the same level ends the current one
+		if last := len(open) - 1; last >= 0 && open[last].level == level && open[last].key != style.key {
+			open = open[:last]
+		}
+
+		if len(open) == 0 || open[len(open)-1].level < level {
+			list := &LayoutList{
+				styleType: style.styleType,
+				start:     style.startAt + layout.listCounters[style.key],
+				level:     level,
+				key:       style.key,
+			}
+
+			if len(open) == 0 {
+				list.parent = parent
+				result = append(result, list)
+			} else {
+				item := open[len(open)-1].lastItem()
+				list.parent = item
+				item.children = append(item.children, list)
+			}
+			open = append(open, list)
+		}
+
+		list := open[len(open)-1]
+		item := &LayoutListItem{parent: list, children: []LayoutNode{p}}
+		list.items = append(list.items, item)
+
+		// The list draws the indent of its items
+		p.parent = item
+		p.format[FormatTextIndent] = nil
+
+		layout.countListItem(style.key)
+	}
+
+	return result
+}
+
+// countListItem counts an item of a list level and restarts the numbering of
+// the deeper levels.
+func (layout *Layout) countListItem(key listKey) {
+	layout.listCounters[key] += 1
+
+	for k := range layout.listCounters {
+		if k.index == key.index && k.level > key.level {
+			delete(layout.listCounters, k)
+		}
+	}
+}
+
+// lastItem returns the last item of the list, adding an empty one to a list
+// that has none yet.
+func (l *LayoutList) lastItem() *LayoutListItem {
+	if len(l.items) == 0 {
+		l.items = append(l.items, &LayoutListItem{parent: l})
+	}
+	return l.items[len(l.items)-1]
+}
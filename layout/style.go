@@ -0,0 +1,150 @@
+package layout
+
+import (
+	"rtf-parser/parser"
+)
+
+var (
+	// Formats of a style definition that do not describe the style itself but
+	// act on the document, and are left out of the style.
+	styleExcludedFormatKinds = map[parser.TextFormatKind]bool{
+		parser.TextFormatParagraphClear: true,
+		parser.TextFormatParagraphEnd:   true,
+		parser.TextFormatPlain:          true,
+		parser.TextFormatInTable:        true,
+		parser.TextFormatTableDepth:     true,
+		parser.TextFormatCellEnd:        true,
+		parser.TextFormatNestedCellEnd:  true,
+		parser.TextFormatRowEnd:         true,
+		parser.TextFormatNestedRowEnd:   true,
+		parser.TextFormatListIndex:      true,
+		parser.TextFormatListLevel:      true,
+		parser.TextFormatParagraphStyle: true,
+		parser.TextFormatCharacterStyle: true,
+		parser.TextFormatSectionStyle:   true,
+	}
+)
+
+type (
+	styleKey struct {
+		kind  parser.StyleKind
+		index int
+	}
+
+	// styleReference marks the point of the format stack where \sN or \csN
+	// applied a style. Its formats are expanded in place when building the
+	// format, below any format pushed after it.
+	styleReference struct {
+		style     *Style
+		character bool
+	}
+)
+
+func (r styleReference) kind() FormatKind {
+	return FormatMAX
+}
+
+func (r styleReference) concat(other FormatOp) FormatOp {
+	return r
+}
+
+// storeStyles resolves the styles of the stylesheet, each with the formats it
+// inherits through \sbasedon.
+func (layout *Layout) storeStyles(sheet parser.StyleSheet) {
+	definitions := map[styleKey]parser.Style{}
+	for _, entity := range sheet.Styles() {
+		s := entity.(parser.Style)
+		definitions[styleKey{kind: s.StyleKind(), index: s.Index()}] = s
+	}
+
+	for key := range definitions {
+		layout.resolveStyle(key, definitions, map[styleKey]bool{})
+	}
+}
+
+// resolveStyle returns the style of the key, resolving the styles it is based
+// on first. Visited guards against writers producing inheritance cycles.
+func (layout *Layout) resolveStyle(key styleKey, definitions map[styleKey]parser.Style, visited map[styleKey]bool) *Style {
+	if style, exist := layout.styles[key]; exist {
+		return style
+	}
+
+	definition, exist := definitions[key]
+	if !exist || visited[key] {
+		return nil
+	}
+	visited[key] = true
+
+	style := &Style{
+		Name: definition.Name(),
+	}
+
+	if definition.BasedOn() >= 0 {
+		base := layout.resolveStyle(styleKey{kind: key.kind, index: definition.BasedOn()}, definitions, visited)
+		if base != nil {
+			style.ops = append(style.ops, base.ops...)
+		}
+	}
+	style.ops = append(style.ops, layout.styleOps(definition.Formats())...)
+
+	// The format of the style on its own, as buildFormat would resolve it
+	saved := layout.formatStack
+	layout.formatStack = style.ops
+	style.Format = layout.buildFormat()
+	layout.formatStack = saved
+
+	layout.styles[key] = style
+	return style
+}
+
+// styleOps converts the formats of a style definition to format ops, the same
+// way they would be pushed in the document.
+func (layout *Layout) styleOps(formats []parser.Entity) []FormatOp {
+	saved := layout.formatStack
+	layout.formatStack = nil
+
+	for _, entity := range formats {
+		t, ok := entity.(parser.TextFormat)
+		if !ok || styleExcludedFormatKinds[t.FormatKind()] {
+			continue
+		}
+		layout.processFormat(t)
+	}
+
+	ops := layout.formatStack
+	layout.formatStack = saved
+	return ops
+}
+
+// pushStyle applies the style of the \sN or \csN word, styles missing from
+// the stylesheet are ignored.
+func (layout *Layout) pushStyle(kind parser.StyleKind, index int) {
+	style, exist := layout.styles[styleKey{kind: kind, index: max(index, 0)}]
+	if !exist {
+		return
+	}
+
+	layout.pushFormat(styleReference{
+		style:     style,
+		character: kind == parser.StyleKindCharacter,
+	})
+}
+
+// currentStyle returns the last paragraph or character style applied in the
+// format stack, nil if none. A \plain after a character style ends it.
+func (layout *Layout) currentStyle(character bool) *Style {
+	for i := len(layout.formatStack) - 1; i >= 0; i -= 1 {
+		switch f := layout.formatStack[i].(type) {
+		case characterReset:
+			if character {
+				return nil
+			}
+		case styleReference:
+			if f.character == character {
+				return f.style
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,334 @@
+package layout
+
+import (
+	"rtf-parser/parser"
+)
+
+const (
+	// Width of a border without \brdrw, in twips
+	defaultBorderWidth = 15
+)
+
+var (
+	tableAlignLookup = map[parser.TableRowAlignKind]TableAlign{
+		parser.TableRowAlignLeft:   TableAlignLeft,
+		parser.TableRowAlignCenter: TableAlignCenter,
+		parser.TableRowAlignRight:  TableAlignRight,
+	}
+
+	cellAlignLookup = map[parser.TableCellAlignKind]CellAlign{
+		parser.TableCellAlignTop:    CellAlignTop,
+		parser.TableCellAlignCenter: CellAlignMiddle,
+		parser.TableCellAlignBottom: CellAlignBottom,
+	}
+
+	borderStyleLookup = map[parser.BorderStyleKind]BorderStyle{
+		parser.BorderStyleNone:     BorderStyleNone,
+		parser.BorderStyleSingle:   BorderStyleSolid,
+		parser.BorderStyleThick:    BorderStyleSolid,
+		parser.BorderStyleDouble:   BorderStyleDouble,
+		parser.BorderStyleDotted:   BorderStyleDotted,
+		parser.BorderStyleDashed:   BorderStyleDashed,
+		parser.BorderStyleHairline: BorderStyleSolid,
+	}
+)
+
+type (
+	// tableState tracks the table being filled at one nesting depth. RTF
+	// describes a row only once its cells are written, so the row and cell
+	// properties are applied when the row ends.
+	tableState struct {
+		table *LayoutTable
+		row   *LayoutTableRow
+		cell  *LayoutTableCell
+		// Cells starting a vertical merge, by right boundary
+		verticalMerges map[int]*LayoutTableCell
+	}
+)
+
+// enterTable moves the paragraph started by the last \pard into a cell of the
+// table at depth, once \intbl or \itap reveal it belongs to one.
+func (layout *Layout) enterTable(depth int) {
+	layout.tableDepth = depth
+
+	p := layout.pendingParagraph
+	if p == nil || len(p.children) > 0 {
+		return
+	}
+	if depth <= len(layout.tables) && p.parent == LayoutNode(layout.tables[depth-1].cell) {
+		return
+	}
+
+	anchor := p.parent
+	layout.detachNode(p)
+
+	cell := layout.openCell(depth, anchor)
+	p.parent = cell
+	cell.children = append(cell.children, p)
+	layout.currentNode = p
+}
+
+// enterCellParagraph makes sure text in a table goes to a paragraph of the
+// current cell at the current depth.
+func (layout *Layout) enterCellParagraph() {
+	cell := layout.openCell(layout.tableDepth, layout.tableAnchor())
+	if p := layout.currentNode; p != nil && !p.ended && p.parent == LayoutNode(cell) {
+		return
+	}
+
+	p := &LayoutParagraph{parent: cell}
+	cell.children = append(cell.children, p)
+	layout.currentNode = p
+}
+
+// endCell closes the cell at depth, creating an empty one when no content was
+// written to it.
+func (layout *Layout) endCell(depth int) {
+	cell := layout.openCell(depth, layout.tableAnchor())
+	if layout.currentNode != nil && layout.currentNode.parent == LayoutNode(cell) {
+		layout.currentNode.format = layout.buildFormat().paragraphFormat()
+		layout.currentNode.style = layout.currentStyle(false)
+		layout.currentNode.ended = true
+	}
+
+	layout.tables[depth-1].cell = nil
+}
+
+// endRow closes the row at depth and applies the last row definition given
+// for that depth to its cells.
+func (layout *Layout) endRow(depth int) {
+	if depth > len(layout.tables) {
+		return
+	}
+
+	state := layout.tables[depth-1]
+	if state.row == nil {
+		return
+	}
+
+	// Word starts a paragraph in the table before the row properties that end
+	// each row, which opens a cell no \cell ever closes
+	if state.cell != nil && isEmptyCell(state.cell) {
+		state.row.cells = state.row.cells[:len(state.row.cells)-1]
+	}
+
+	if def, exist := layout.rowDefinitions[depth]; exist {
+		layout.applyRowDefinition(state, def)
+	}
+
+	state.row = nil
+	state.cell = nil
+	layout.tables = layout.tables[:depth]
+}
+
+// openCell returns the open cell of the table at depth, starting the tables,
+// row and cell it needs. A new outermost table is added to anchor.
+func (layout *Layout) openCell(depth int, anchor LayoutNode) *LayoutTableCell {
+	// The outermost table is over once anything else follows it
+	if len(layout.tables) > 0 && !layout.isLastChild(layout.tables[0].table) {
+		layout.tables = layout.tables[:0]
+	}
+
+	for len(layout.tables) < depth {
+		parent := anchor
+		if d := len(layout.tables); d > 0 {
+			parent = layout.openCell(d, anchor)
+		}
+
+		table := &LayoutTable{parent: parent}
+		layout.appendChild(parent, table)
+		layout.tables = append(layout.tables, &tableState{
+			table:          table,
+			verticalMerges: map[int]*LayoutTableCell{},
+		})
+	}
+
+	state := layout.tables[depth-1]
+	if state.row == nil {
+		state.row = &LayoutTableRow{parent: state.table}
+		state.table.rows = append(state.table.rows, state.row)
+	}
+
+	if state.cell == nil {
+		state.cell = &LayoutTableCell{parent: state.row, colSpan: 1, rowSpan: 1}
+		state.row.cells = append(state.row.cells, state.cell)
+
+		// Tables nested in the previous cell are complete
+		layout.tables = layout.tables[:depth]
+	}
+
+	return state.cell
+}
+
+// tableAnchor returns the node a new outermost table is added to
+func (layout *Layout) tableAnchor() LayoutNode {
+	if len(layout.tables) > 0 {
+		return layout.tables[0].table.parent
+	}
+
+	if layout.currentNode == nil {
+		return nil
+	}
+
+	return layout.currentNode.parent
+}
+
+// paragraphParent returns the node a paragraph started by \pard is added to
+func (layout *Layout) paragraphParent() LayoutNode {
+	if layout.currentNode == nil {
+		return nil
+	}
+
+	// Paragraphs following a table are its siblings, unless \intbl moves them
+	// back into it
+	if len(layout.tables) > 0 && isDescendant(layout.currentNode, layout.tables[0].table) {
+		return layout.tables[0].table.parent
+	}
+
+	// A new paragraph follows an ended one, and nests in one still open
+	if layout.currentNode.ended {
+		return layout.currentNode.parent
+	}
+
+	return layout.currentNode
+}
+
+func (layout *Layout) applyRowDefinition(state *tableState, def parser.TableRow) {
+	row := state.row
+	row.height = def.Height()
+	row.header = def.Header()
+
+	if len(state.table.rows) == 1 {
+		state.table.align = tableAlignLookup[def.AlignKind()]
+		state.table.left = def.Left()
+	}
+
+	defs := def.Cells()
+	left := def.Left()
+	var spanOwner *LayoutTableCell
+
+	for i, cell := range row.cells {
+		if i >= len(defs) {
+			break
+		}
+
+		cellDef := defs[i].(parser.TableCell)
+		cell.width = cellDef.Boundary() - left
+		left = cellDef.Boundary()
+		cell.verticalAlign = cellAlignLookup[cellDef.AlignKind()]
+
+		if clr, exist := layout.lookupColor(cellDef.Shading()); exist {
+			cell.background = &clr
+		}
+
+		for side := parser.TableBorderSide(0); side < parser.TableBorderMAX; side += 1 {
+			cell.borders[side] = layout.resolveBorder(cellDef.Border(side))
+		}
+
+		switch cellDef.MergeKind() {
+		case parser.TableCellMergeFirst:
+			spanOwner = cell
+		case parser.TableCellMergeContinue:
+			if spanOwner != nil {
+				spanOwner.colSpan += 1
+				spanOwner.width += cell.width
+				cell.merged = true
+			}
+		default:
+			spanOwner = nil
+		}
+
+		switch cellDef.VerticalMerge() {
+		case parser.TableCellMergeFirst:
+			state.verticalMerges[cellDef.Boundary()] = cell
+		case parser.TableCellMergeContinue:
+			if owner, exist := state.verticalMerges[cellDef.Boundary()]; exist {
+				owner.rowSpan += 1
+				cell.merged = true
+			}
+		default:
+			delete(state.verticalMerges, cellDef.Boundary())
+		}
+	}
+}
+
+func (layout *Layout) resolveBorder(b parser.TableBorder) Border {
+	border := Border{
+		Style: borderStyleLookup[b.StyleKind()],
+		Width: b.Width(),
+	}
+
+	if border.Style == BorderStyleNone {
+		return Border{}
+	}
+
+	if border.Width == 0 {
+		border.Width = defaultBorderWidth
+	}
+	if b.StyleKind() == parser.BorderStyleThick {
+		border.Width *= 2
+	}
+
+	clr, exist := layout.lookupColor(b.Color())
+	border.Color = clr
+	border.AutoColor = !exist
+
+	return border
+}
+
+// childrenOf returns the children of a node that holds paragraphs and tables,
+// the layout roots for a nil parent.
+func (layout *Layout) childrenOf(parent LayoutNode) *[]LayoutNode {
+	switch p := parent.(type) {
+	case *LayoutParagraph:
+		if p != nil {
+			return &p.children
+		}
+	case *LayoutTableCell:
+		if p != nil {
+			return &p.children
+		}
+	}
+
+	return &layout.roots
+}
+
+func (layout *Layout) appendChild(parent LayoutNode, node LayoutNode) {
+	children := layout.childrenOf(parent)
+	*children = append(*children, node)
+}
+
+func (layout *Layout) detachNode(node LayoutNode) {
+	children := layout.childrenOf(node.Parent())
+	for i := len(*children) - 1; i >= 0; i -= 1 {
+		if (*children)[i] == node {
+			*children = append((*children)[:i], (*children)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (layout *Layout) isLastChild(node LayoutNode) bool {
+	children := *layout.childrenOf(node.Parent())
+	return len(children) > 0 && children[len(children)-1] == node
+}
+
+// isEmptyCell reports whether a cell holds nothing but empty paragraphs
+func isEmptyCell(cell *LayoutTableCell) bool {
+	for _, child := range cell.children {
+		p, ok := child.(*LayoutParagraph)
+		if !ok || len(p.children) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isDescendant(node LayoutNode, ancestor LayoutNode) bool {
+	for n := node; n != nil; n = n.Parent() {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package layout
+
+import (
+	"fmt"
+	"testing"
+
+	"rtf-parser/parser"
+)
+
+// wordRow is a row the way Word writes it: the row properties are repeated
+// in a group after the cells, in a paragraph of its own
+const wordRow = `\trowd \irow0\irowband0\ltrrow\ts11\trgaph108\trleft-108\cellx4428\cellx8964` +
+	`\pard\plain \ltrpar\ql \li0\ri0\widctlpar\intbl\wrapdefault\aspalpha\aspnum\faauto\adjustright\rin0\lin0 ` +
+	`{\rtlch\fcs1 \af0 \ltrch\fcs0 %s\cell %s\cell }` +
+	`\pard\plain \ltrpar\ql \li0\ri0\sa200\sl276\slmult1\widctlpar\intbl\wrapdefault\aspalpha\aspnum\faauto\adjustright\rin0\lin0 ` +
+	`{\rtlch\fcs1 \af0 \ltrch\fcs0 \trowd \irow0\irowband0\ltrrow\ts11\trgaph108\trleft-108\cellx4428\cellx8964\row }`
+
+func buildTable(t *testing.T, body string) *LayoutTable {
+	t.Helper()
+
+	ops, err := parser.Parse(`{\rtf1\ansi` + body + `\pard after\par}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, root := range BuildLayout(ops) {
+		if table, ok := root.(*LayoutTable); ok {
+			return table
+		}
+	}
+	t.Fatalf("no table laid out")
+	return nil
+}
+
+func TestWordRowEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		cells []int
+	}{
+		{name: "one row", body: `\trowd\cellx100\cellx200\pard\intbl AA\cell BB\cell \pard\intbl {\trowd\cellx100\cellx200\row }`, cells: []int{2}},
+		{name: "word rows", body: fmt.Sprintf(wordRow, "A1", "B1") + fmt.Sprintf(wordRow, "A2", "B2"), cells: []int{2, 2}},
+		{name: "empty last cell", body: `\trowd\cellx100\cellx200\pard\intbl AA\cell \cell \pard\intbl {\trowd\cellx100\cellx200\row }`, cells: []int{2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows := buildTable(t, test.body).Rows()
+			if len(rows) != len(test.cells) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(test.cells))
+			}
+			for i, row := range rows {
+				if len(row.Cells()) != test.cells[i] {
+					t.Errorf("row %d: got %d cells, want %d", i, len(row.Cells()), test.cells[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package layout
+
+const (
+	// DefaultBaseFontSize is the font size in points em and rem values are
+	// relative to, unless another one is given
+	DefaultBaseFontSize = 12.0
+
+	pointsPerTwip      = 1.0 / 20
+	pointsPerHalfPoint = 1.0 / 2
+	// CSS pixels are 1/96 inch
+	pointsPerPixel = 72.0 / 96
+)
+
+type MeasuringUnit int
+
+const (
+	MeasuringUnitPoint MeasuringUnit = iota
+	MeasuringUnitTwip
+	MeasuringUnitEm
+	MeasuringUnitPixel
+	MeasuringUnitHalfPoint
+	MeasuringUnitRem
+)
+
+// ConvertUnits converts a measure from one unit to another. Em and rem values
+// are relative to baseFontSize, in points, or to DefaultBaseFontSize when it
+// is 0.
+func ConvertUnits(value float64, from MeasuringUnit, to MeasuringUnit, baseFontSize float64) float64 {
+	if baseFontSize <= 0 {
+		baseFontSize = DefaultBaseFontSize
+	}
+
+	return value * pointsPer(from, baseFontSize) / pointsPer(to, baseFontSize)
+}
+
+func pointsPer(unit MeasuringUnit, baseFontSize float64) float64 {
+	switch unit {
+	case MeasuringUnitTwip:
+		return pointsPerTwip
+	case MeasuringUnitHalfPoint:
+		return pointsPerHalfPoint
+	case MeasuringUnitPixel:
+		return pointsPerPixel
+	case MeasuringUnitEm, MeasuringUnitRem:
+		return baseFontSize
+	}
+	return 1
+}
+
+// func accessBitUint8(val uint8, n uint8) bool {
+// 	var mask uint8 = 1 << n
+// 	return (val&mask)>>n == 1
+// }
+
+// func setBitUint8(val uint8, n uint8) uint8 {
+// 	var mask uint8 = 1 << n
+// 	return val | mask
+// }
+
+// func clearBitUint8(val uint8, n uint8) uint8 {
+// 	var mask uint8 = 1 << n
+// 	mask = ^mask
+// 	return val & mask
+// }
@@ -0,0 +1,29 @@
+package lexer
+
+import "fmt"
+
+var (
+	tokenKindStr = map[TokenKind]string{
+		TokenInvalid:       "TokenInvalid",
+		TokenNewline:       "TokenNewline",
+		TokenEOF:           "TokenEOF",
+		TokenOpenBracket:   "TokenOpenBracket",
+		TokenCloseBracket:  "TokenCloseBracket",
+		TokenBackslash:     "TokenBackslash",
+		TokenSemicolon:     "TokenSemicolon",
+		TokenDash:          "TokenDash",
+		TokenString:        "TokenString",
+		TokenNumber:        "TokenNumber",
+		TokenWhitespace:    "TokenWhitespace",
+		TokenHexEscape:     "TokenHexEscape",
+		TokenControlSymbol: "TokenControlSymbol",
+	}
+)
+
+func (k TokenKind) String() string {
+	return tokenKindStr[k]
+}
+
+func PrintToken(t Token) {
+	fmt.Printf("[%s] %s (from %d to %d)\n", tokenKindStr[t.kind], t.text, t.start, t.end)
+}
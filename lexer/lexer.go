@@ -0,0 +1,434 @@
+// Package lexer splits raw RTF input into a stream of tokens.
+//
+// Spaces are significant in RTF text, except for the single space that may
+// delimit a control word from the text after it. That space is consumed along
+// with the last token of the word, its name or its parameter, and is not part
+// of the token: the next token starts after it. Every other byte is left to
+// the text.
+//
+// A lexer reads either a string held in memory or an io.Reader. Reading from
+// a reader, it keeps a bounded window of the input: the bytes before the
+// offset given to Release are dropped as the window moves on.
+package lexer
+
+import "io"
+
+const (
+	// Size of each read of a lexer reading from an io.Reader
+	readChunkSize = 64 * 1024
+	// Bytes kept before the released offset, for the lexer to look back at
+	// the control word a token belongs to
+	lookbehindSize = 64
+)
+
+const (
+	TokenInvalid TokenKind = iota
+	TokenNewline
+	TokenEOF
+
+	TokenOpenBracket
+	TokenCloseBracket
+	TokenBackslash
+	TokenSemicolon
+	TokenDash
+
+	TokenString
+	TokenNumber
+	TokenWhitespace
+	TokenHexEscape
+	TokenControlSymbol
+)
+
+type (
+	Lexer struct {
+		// Window of the input, input[0] being the byte at offset base
+		input   string
+		base    int
+		current int
+		release int
+
+		// Reader the window is filled from, nil once it is exhausted or for a
+		// lexer over a string
+		reader io.Reader
+		chunk  []byte
+		err    error
+	}
+
+	TokenKind int
+
+	// Token is the span of the input between two byte offsets. Its text is a
+	// view of the input, not a copy.
+	Token struct {
+		kind  TokenKind
+		text  string
+		start int
+		end   int
+	}
+)
+
+// New returns a lexer positioned at the start of the input.
+func New(input string) Lexer {
+	lexer := Lexer{
+		input:   input,
+		current: 0,
+	}
+
+	return lexer
+}
+
+// NewReader returns a lexer reading the input from r as tokens are lexed,
+// holding only a window of it in memory.
+func NewReader(r io.Reader) Lexer {
+	lexer := Lexer{
+		reader: r,
+	}
+
+	return lexer
+}
+
+func (lexer *Lexer) NextToken() Token {
+	result := Token{
+		start: lexer.current,
+	}
+
+	// lexer.skipWhitespace()
+	if lexer.isEOF() {
+		result.kind = TokenEOF
+		result.end = lexer.current
+		return result
+	}
+
+	c := lexer.advance()
+
+	switch c {
+	case '\n':
+		result.kind = TokenNewline
+	case '\r':
+		if !lexer.isEOF() && lexer.peek() == '\n' {
+			lexer.advance()
+		}
+		result.kind = TokenNewline
+	case '\\':
+		// A \'hh escape is lexed as a single token so that the two hex digits
+		// are never mistaken for the start of a word or a number
+		if lexer.isHexEscape() {
+			lexer.current += 3
+			result.kind = TokenHexEscape
+		} else if lexer.isControlSymbol() {
+			// Likewise a backslash followed by any other character that cannot
+			// start a word (\{, \\, \~, ...) is a control symbol, so that an
+			// escaped backslash never starts an escape of its own
+			lexer.current += 1
+			result.kind = TokenControlSymbol
+		} else {
+			result.kind = TokenBackslash
+		}
+	case '{':
+		result.kind = TokenOpenBracket
+	case '}':
+		result.kind = TokenCloseBracket
+	case ';':
+		result.kind = TokenSemicolon
+	case '-':
+		result.kind = TokenDash
+	case ' ', '\t':
+	lexWhitespace:
+		for {
+			if lexer.isEOF() {
+				break lexWhitespace
+			}
+
+			if !isWhitespace(lexer.peek()) {
+				break lexWhitespace
+			}
+
+			lexer.advance()
+		}
+		result.kind = TokenWhitespace
+	default:
+		if isLetter(c) {
+		lexString:
+			for {
+				if lexer.isEOF() {
+					break lexString
+				}
+
+				next := lexer.peek()
+				if !isLetter(next) {
+					break lexString
+				}
+
+				lexer.advance()
+			}
+			result.kind = TokenString
+		} else if isNumber(c) {
+		lexNumber:
+			for {
+				if lexer.isEOF() {
+					break lexNumber
+				}
+
+				next := lexer.peek()
+				if !isNumber(next) {
+					break lexNumber
+				}
+
+				lexer.advance()
+			}
+			result.kind = TokenNumber
+
+		} else if c >= 0x80 {
+			// Bytes outside ASCII are kept together, so that the text can
+			// tell UTF-8 sequences from code page characters
+			for !lexer.isEOF() && lexer.peek() >= 0x80 {
+				lexer.advance()
+			}
+			result.kind = TokenInvalid
+		} else {
+			result.kind = TokenInvalid
+		}
+	}
+
+	result.end = lexer.current
+	result.text = lexer.input[result.start-lexer.base : result.end-lexer.base]
+
+	if lexer.endsControlWord(result) {
+		lexer.skipDelimiter()
+	}
+
+	return result
+}
+
+// endsControlWord reports whether a token is the last one of a control word:
+// its name when no parameter follows, or its parameter.
+func (lexer *Lexer) endsControlWord(t Token) bool {
+	switch t.kind {
+	case TokenString:
+		return !lexer.startsParameter() && lexer.isControlWordStart(t.start)
+
+	case TokenNumber:
+		start := t.start
+		if start > lexer.base && lexer.at(start-1) == '-' {
+			start -= 1
+		}
+
+		// The parameter follows the letters of the name
+		nameStart := start
+		for nameStart > lexer.base && isLetter(lexer.at(nameStart-1)) {
+			nameStart -= 1
+		}
+		return nameStart < start && lexer.isControlWordStart(nameStart)
+	}
+
+	return false
+}
+
+// isControlWordStart reports whether the letters at offset follow a backslash
+// starting a control word, rather than the second one of an escaped \\. Only
+// the backslashes still in the window are counted.
+func (lexer *Lexer) isControlWordStart(offset int) bool {
+	backslashes := 0
+	for i := offset - 1; i >= lexer.base && lexer.at(i) == '\\'; i -= 1 {
+		backslashes += 1
+	}
+
+	return backslashes%2 == 1
+}
+
+// startsParameter reports whether the input continues with a number,
+// optionally negative
+func (lexer *Lexer) startsParameter() bool {
+	next := lexer.current
+	if lexer.fill(next) && lexer.at(next) == '-' {
+		next += 1
+	}
+
+	return lexer.fill(next) && isNumber(lexer.at(next))
+}
+
+func (lexer *Lexer) skipDelimiter() {
+	if !lexer.isEOF() && lexer.peek() == ' ' {
+		lexer.advance()
+	}
+}
+
+// Position returns the byte offset of the next token.
+func (lexer *Lexer) Position() int {
+	return lexer.current
+}
+
+// SetPosition moves the lexer back (or forward) to a byte offset previously
+// returned by Position. The offset may not be before the released one.
+func (lexer *Lexer) SetPosition(offset int) {
+	lexer.current = offset
+}
+
+// Release tells the lexer the input before offset is not needed anymore: the
+// position will not be set back before it and no source before it will be
+// asked for. Releasing an offset before an already released one does nothing.
+func (lexer *Lexer) Release(offset int) {
+	lexer.release = max(lexer.release, offset)
+}
+
+// Source returns the raw input between two byte offsets, which must not be
+// released yet.
+func (lexer *Lexer) Source(start int, end int) string {
+	if end > start {
+		lexer.fill(end - 1)
+	}
+	end = min(end, lexer.base+len(lexer.input))
+
+	return lexer.input[start-lexer.base : end-lexer.base]
+}
+
+// ReadBytes returns the next n bytes of the input as they are, or the
+// remaining ones when the input is shorter, and moves past them. The bytes
+// are released: binary data, like the one following \binN, is never lexed.
+func (lexer *Lexer) ReadBytes(n int) []byte {
+	data := []byte{}
+	for len(data) < n && lexer.fill(lexer.current) {
+		buffered := lexer.input[lexer.current-lexer.base:]
+		count := min(n-len(data), len(buffered))
+
+		data = append(data, buffered[:count]...)
+		lexer.current += count
+		lexer.Release(lexer.current)
+	}
+
+	return data
+}
+
+// Len returns the length of the input in bytes. For a lexer reading from an
+// io.Reader, this is the length of the input read so far.
+func (lexer *Lexer) Len() int {
+	return lexer.base + len(lexer.input)
+}
+
+// Err returns the error the reader of the lexer failed with, if any. The
+// lexer returns TokenEOF from the point of the failure.
+func (lexer *Lexer) Err() error {
+	return lexer.err
+}
+
+// fill reads the input until the byte at offset is in the window, and reports
+// whether the input is that long.
+func (lexer *Lexer) fill(offset int) bool {
+	for offset >= lexer.base+len(lexer.input) {
+		if lexer.reader == nil {
+			return false
+		}
+		lexer.read()
+	}
+
+	return true
+}
+
+// read appends the next chunk of the reader to the window, dropping the
+// released bytes but the last lookbehindSize ones. Chunks grow with what is
+// kept, so that copying it stays linear in the length of the input.
+func (lexer *Lexer) read() {
+	keep := max(lexer.release-lookbehindSize, lexer.base)
+	kept := lexer.input[keep-lexer.base:]
+
+	size := max(readChunkSize, len(kept))
+	if cap(lexer.chunk) < size {
+		lexer.chunk = make([]byte, size)
+	}
+
+	n, err := io.ReadFull(lexer.reader, lexer.chunk[:size])
+	if err != nil {
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			lexer.err = err
+		}
+		lexer.reader = nil
+	}
+
+	// The window is a new string, the tokens still holding the previous one
+	// keep it alive
+	lexer.input = kept + string(lexer.chunk[:n])
+	lexer.base = keep
+}
+
+func (lexer *Lexer) skipWhitespace() {
+	for {
+		if lexer.isEOF() {
+			return
+		}
+
+		c := lexer.peek()
+		if !(c == '\r' || c == '\t' || c == '\b' || c == ' ') {
+			break
+		}
+		lexer.advance()
+	}
+}
+
+func (lexer *Lexer) isEOF() bool {
+	return !lexer.fill(lexer.current)
+}
+
+func (lexer *Lexer) advance() byte {
+	c := lexer.at(lexer.current)
+	lexer.current += 1
+	return c
+}
+
+func (lexer *Lexer) peek() byte {
+	return lexer.at(lexer.current)
+}
+
+// at returns the byte at offset, which must be in the window
+func (lexer *Lexer) at(offset int) byte {
+	return lexer.input[offset-lexer.base]
+}
+
+func (lexer *Lexer) isHexEscape() bool {
+	if !lexer.fill(lexer.current + 2) {
+		return false
+	}
+
+	return lexer.at(lexer.current) == '\'' &&
+		isHexDigit(lexer.at(lexer.current+1)) &&
+		isHexDigit(lexer.at(lexer.current+2))
+}
+
+func (t Token) Kind() TokenKind {
+	return t.kind
+}
+
+func (t Token) Text() string {
+	return t.text
+}
+
+func (t Token) Start() int {
+	return t.start
+}
+
+func (t Token) End() int {
+	return t.end
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNumber(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (lexer *Lexer) isControlSymbol() bool {
+	if lexer.isEOF() {
+		return false
+	}
+
+	c := lexer.peek()
+	return !isLetter(c) && !isNumber(c)
+}
+
+func isHexDigit(c byte) bool {
+	return isNumber(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isWhitespace(c byte) bool {
+	return c == '\t' || c == ' '
+}
@@ -0,0 +1,71 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"rtf-parser/internal/samples"
+)
+
+// Allocation target of lexing a document held in a string: tokens are views
+// of the input, so lexing allocates nothing.
+const lexAllocsTarget = 0
+
+func lexAll(input string) {
+	l := New(input)
+	for l.NextToken().Kind() != TokenEOF {
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	for name, input := range samples.Inputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i += 1 {
+				lexAll(input)
+			}
+		})
+	}
+}
+
+func TestLexAllocations(t *testing.T) {
+	if samples.RaceEnabled {
+		t.Skip("the race detector changes allocations")
+	}
+
+	for name, input := range samples.Inputs(t) {
+		if allocs := testing.AllocsPerRun(10, func() { lexAll(input) }); allocs > lexAllocsTarget {
+			t.Errorf("%s: lexing allocates %.0f times, target %d", name, allocs, lexAllocsTarget)
+		}
+	}
+}
+
+func TestDelimiterSpace(t *testing.T) {
+	tests := []struct {
+		input string
+		texts []string
+	}{
+		// The space after a word or its parameter is not part of the text
+		{input: `\b bold`, texts: []string{`\`, "b", "bold"}},
+		{input: `\fs24 big`, texts: []string{`\`, "fs", "24", "big"}},
+		{input: `\li-720 text`, texts: []string{`\`, "li", "-", "720", "text"}},
+		// Only the first space is the delimiter
+		{input: `\b  two`, texts: []string{`\`, "b", " ", "two"}},
+		// Text after an escaped backslash is not a word
+		{input: `\\b bold`, texts: []string{`\\`, "b", " ", "bold"}},
+		{input: "a\r\nb", texts: []string{"a", "\r\n", "b"}},
+	}
+
+	for _, test := range tests {
+		l := New(test.input)
+		texts := []string{}
+		for token := l.NextToken(); token.Kind() != TokenEOF; token = l.NextToken() {
+			texts = append(texts, token.Text())
+		}
+
+		if strings.Join(texts, "|") != strings.Join(test.texts, "|") {
+			t.Errorf("%q: tokens %q, want %q", test.input, texts, test.texts)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"rtf-parser/internal/samples"
+)
+
+var (
+	// Allocation targets of parsing the sample documents, a few percent over
+	// the measured counts. The parser allocates for entities and the text
+	// they hold, never per token, and streaming reuses its read buffer.
+	parseAllocsTargets = map[string]float64{
+		"simple.rtf":  48,
+		"regular.rtf": 215,
+	}
+)
+
+func streamInput(input string) error {
+	p := NewReader(strings.NewReader(input), ParsingOptions{})
+	for {
+		_, err := p.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for name, input := range samples.Inputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i += 1 {
+				if _, err := Parse(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStream(b *testing.B) {
+	for name, input := range samples.Inputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i += 1 {
+				if err := streamInput(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAllocations(t *testing.T) {
+	if samples.RaceEnabled {
+		t.Skip("the race detector changes allocations")
+	}
+
+	for name, input := range samples.Inputs(t) {
+		target, exist := parseAllocsTargets[name]
+		if !exist {
+			t.Errorf("%s: no allocation target", name)
+			continue
+		}
+
+		if allocs := testing.AllocsPerRun(10, func() { Parse(input) }); allocs > target {
+			t.Errorf("%s: parsing allocates %.0f times, target %.0f", name, allocs, target)
+		}
+		if allocs := testing.AllocsPerRun(10, func() { streamInput(input) }); allocs > target {
+			t.Errorf("%s: streaming allocates %.0f times, target %.0f", name, allocs, target)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	OpDebugger struct {
+		ops     []Entity
+		output  []debugInfo
+		builder strings.Builder
+	}
+
+	debugInfo struct {
+		indent    int
+		op        Entity
+		userIndex int
+	}
+)
+
+func DebugOpStream(ops []Entity) {
+	d := OpDebugger{
+		ops:     ops,
+		builder: strings.Builder{},
+	}
+
+	d.buildInfoIndentation()
+
+	for _, info := range d.output {
+		for i := 0; i < info.indent; i += 1 {
+			d.builder.WriteByte('\t')
+		}
+
+		d.buildDebugMessage(info)
+		d.builder.WriteByte('\n')
+	}
+
+	fmt.Println(d.builder.String())
+}
+
+func (d *OpDebugger) buildInfoIndentation() {
+	indent := 0
+
+	for _, op := range d.ops {
+		idx := len(d.output)
+		d.output = append(d.output, debugInfo{
+			op:     op,
+			indent: indent,
+		})
+
+		switch e := op.(type) {
+		case ControlGroup:
+			if e.groupKind == ControlGroupKindBegin {
+				indent += 1
+			} else {
+				d.output[idx].indent -= 1
+				indent -= 1
+			}
+
+		case FontTable:
+			for i, fnt := range e.fonts {
+				d.output = append(d.output, debugInfo{
+					op:        fnt,
+					indent:    d.output[idx].indent + 1,
+					userIndex: i,
+				})
+			}
+
+		case ColorTable:
+			for i, clr := range e.colors {
+				d.output = append(d.output, debugInfo{
+					op:        clr,
+					indent:    d.output[idx].indent + 1,
+					userIndex: i,
+				})
+			}
+
+		case ListTable:
+			for i, list := range e.lists {
+				d.output = append(d.output, debugInfo{
+					op:        list,
+					indent:    d.output[idx].indent + 1,
+					userIndex: i,
+				})
+				for j, level := range list.(List).levels {
+					d.output = append(d.output, debugInfo{
+						op:        level,
+						indent:    d.output[idx].indent + 2,
+						userIndex: j,
+					})
+				}
+			}
+
+		case StyleSheet:
+			for i, style := range e.styles {
+				d.output = append(d.output, debugInfo{
+					op:        style,
+					indent:    d.output[idx].indent + 1,
+					userIndex: i,
+				})
+				for _, format := range style.(Style).formats {
+					d.output = append(d.output, debugInfo{
+						op:     format,
+						indent: d.output[idx].indent + 2,
+					})
+				}
+			}
+
+		case ListOverrideTable:
+			for i, override := range e.overrides {
+				d.output = append(d.output, debugInfo{
+					op:        override,
+					indent:    d.output[idx].indent + 1,
+					userIndex: i,
+				})
+			}
+
+		case TableRow:
+			for i, cell := range e.cells {
+				d.output = append(d.output, debugInfo{
+					op:        cell,
+					indent:    d.output[idx].indent + 1,
+					userIndex: i,
+				})
+			}
+
+		case FontTableEntry:
+
+		default:
+		}
+	}
+}
+
+func (d *OpDebugger) buildDebugMessage(info debugInfo) {
+	k := info.op.Kind()
+	fmt.Fprintf(&d.builder, "%s", entityKindStr[k])
+
+	switch e := info.op.(type) {
+	case ControlGroup:
+		fmt.Fprintf(&d.builder, " %s", controlGroupKindStr[e.groupKind])
+
+	case ControlWord:
+		fmt.Fprintf(&d.builder, " %s", e.wordToken.Text())
+
+	case ControlSymbol:
+		fmt.Fprintf(&d.builder, " %q", e.symbol)
+
+	case CharacterSet:
+		fmt.Fprintf(&d.builder, " %s", characterSetKindStr[e.setKind])
+		if e.setKind == CharacterSetANSICPG {
+			fmt.Fprintf(&d.builder, " (code page: %d)", e.codePage)
+		}
+
+	case ColorTableEntry:
+		fmt.Fprintf(&d.builder, "%d = (", info.userIndex)
+		for _, channel := range e.channels {
+			if channel == nil {
+				continue
+			}
+			c := channel.(ColorComponent)
+			fmt.Fprintf(&d.builder, "%s: %d; ", c.wordToken.Text(), c.value)
+		}
+		d.builder.WriteByte(')')
+
+	case FontTableEntry:
+		fmt.Fprintf(&d.builder, "(name: ")
+		e.fontName.writeToString(&d.builder)
+
+		fmt.Fprintf(
+			&d.builder,
+			", index: %d, charset: %d, default fallback: %t)",
+			e.index,
+			e.charset,
+			e.defaultFallback,
+		)
+
+	case ColorComponent:
+		fmt.Fprintf(&d.builder, "(channel: %s, value: %d)", e.wordToken.Text(), e.value)
+
+	case TableRow:
+		fmt.Fprintf(&d.builder, " (left: %d, gap: %d, height: %d, header: %t)", e.left, e.gap, e.height, e.header)
+
+	case TableCell:
+		fmt.Fprintf(
+			&d.builder,
+			" %d (boundary: %d, shading: %d, merge: %d, vertical merge: %d)",
+			info.userIndex,
+			e.boundary,
+			e.shading,
+			e.mergeKind,
+			e.verticalMerge,
+		)
+
+	case Picture:
+		fmt.Fprintf(
+			&d.builder,
+			" %s (size: %dx%d, goal: %dx%d, scale: %d%%x%d%%, length: %d)",
+			e.formatKind,
+			e.width,
+			e.height,
+			e.goalWidth,
+			e.goalHeight,
+			e.scaleX,
+			e.scaleY,
+			len(e.data),
+		)
+
+	case Style:
+		fmt.Fprintf(
+			&d.builder,
+			" %s %d (name: %q, based on: %d, next: %d, additive: %t)",
+			e.styleKind,
+			e.index,
+			e.name,
+			e.basedOn,
+			e.next,
+			e.additive,
+		)
+
+	case DocumentInfo:
+		fmt.Fprintf(
+			&d.builder,
+			" (title: %q, subject: %q, author: %q, company: %q, keywords: %q, created: %s, revised: %s, words: %d)",
+			e.title,
+			e.subject,
+			e.author,
+			e.company,
+			e.keywords,
+			e.created.Format(time.DateTime),
+			e.revised.Format(time.DateTime),
+			e.words,
+		)
+
+	case List:
+		fmt.Fprintf(&d.builder, " %d (id: %d)", info.userIndex, e.id)
+
+	case ListLevel:
+		fmt.Fprintf(&d.builder, " %d (format: %s, start at: %d, text: %q)", info.userIndex, e.numberFormat, e.startAt, e.text)
+
+	case ListOverride:
+		fmt.Fprintf(&d.builder, " (list id: %d, index: %d)", e.listID, e.index)
+
+	case ParagraphNumbering:
+		fmt.Fprintf(
+			&d.builder,
+			" (level: %d, format: %s, start at: %d, text before: %q, text after: %q)",
+			e.level,
+			e.numberFormat,
+			e.startAt,
+			e.textBefore,
+			e.textAfter,
+		)
+
+	case Field:
+		fmt.Fprintf(&d.builder, " %s (instruction: %q)", e.fieldKind, e.instruction)
+
+	case TextFormat:
+		if e.arg != -1 {
+			fmt.Fprintf(&d.builder, " %s (arg: %d)", textFormatKindStr[e.formatKind], e.arg)
+		} else {
+			fmt.Fprintf(&d.builder, " %s", textFormatKindStr[e.formatKind])
+		}
+
+	case Destination:
+		fmt.Fprintf(&d.builder, " %s (ignorable: %t, length: %d)", e.Name(), e.ignorable, len(e.raw))
+
+	case Unicode:
+		fmt.Fprintf(&d.builder, " (value: %U, skip: %d)", e.value, e.skip)
+
+	case Text:
+		fmt.Fprintf(&d.builder, ` (value: "`)
+		e.writeToString(&d.builder)
+		d.builder.WriteString(`")`)
+	default:
+	}
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"strings"
+
+	"rtf-parser/lexer"
+)
+
+var (
+	// Switches that take no argument, by field kind. Any other switch takes
+	// the word that follows it.
+	fieldFlagSwitches = map[FieldKind]map[string]bool{
+		FieldHyperlink:  {"m": true, "n": true},
+		FieldDate:       {"h": true, "l": true, "s": true},
+		FieldTOC:        {"h": true, "u": true, "w": true, "x": true, "z": true},
+		FieldMergeField: {"m": true, "v": true},
+	}
+
+	fieldLineBreakReplacer = strings.NewReplacer("\r", "", "\n", "")
+)
+
+type (
+	// fieldWord is a word of a field instruction, quoted words may hold spaces
+	fieldWord struct {
+		text   string
+		quoted bool
+	}
+)
+
+// parseField parses the properties of a \field and its \fldinst group. The
+// \fldrslt group is left to the document.
+func parseField(parser *Parser, word ControlWord) (Entity, error) {
+	field := Field{
+		ControlWord: word,
+	}
+
+parseProperties:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenWhitespace, lexer.TokenNewline, lexer.TokenInvalid:
+			parser.consume()
+
+		case lexer.TokenBackslash:
+			// \flddirty, \fldlock, ...
+			if parser.peekNext().Kind() != lexer.TokenString {
+				break parseProperties
+			}
+			parser.consume()
+			parser.consume()
+			if _, err := parser.parseOptionalNumber(); err != nil {
+				return Field{}, err
+			}
+
+		case lexer.TokenOpenBracket:
+			if parser.peekGroupWord() != "fldinst" {
+				break parseProperties
+			}
+
+			parser.consume()
+			instruction, err := parser.parseGroupText()
+			if err != nil {
+				return Field{}, err
+			}
+			field.instruction = strings.TrimSpace(fieldLineBreakReplacer.Replace(instruction))
+
+		default:
+			break parseProperties
+		}
+	}
+
+	field.parseInstruction()
+	return field, nil
+}
+
+// parseInstruction sets the kind, argument and switches of the field from its
+// instruction text, like HYPERLINK "http://example.com" \o "tooltip".
+func (f *Field) parseInstruction() {
+	words := splitFieldInstruction(f.instruction)
+	if len(words) == 0 {
+		return
+	}
+
+	f.fieldKind = fieldKindLookup[strings.ToUpper(words[0].text)]
+	flags := fieldFlagSwitches[f.fieldKind]
+
+	for i := 1; i < len(words); i += 1 {
+		word := words[i]
+
+		if !word.isSwitch() {
+			if f.argument == "" {
+				f.argument = word.text
+			}
+			continue
+		}
+
+		sw := FieldSwitch{Name: word.text[1:]}
+		if !flags[sw.Name] && i+1 < len(words) && !words[i+1].isSwitch() {
+			sw.Arg = words[i+1].text
+			i += 1
+		}
+		f.switches = append(f.switches, sw)
+	}
+}
+
+func splitFieldInstruction(instruction string) []fieldWord {
+	words := []fieldWord{}
+	current := strings.Builder{}
+	quoted := false
+	inWord := false
+
+	for _, r := range instruction {
+		switch {
+		case r == '"':
+			if quoted {
+				words = append(words, fieldWord{text: current.String(), quoted: true})
+				current.Reset()
+				quoted = false
+				continue
+			}
+			if inWord {
+				words = append(words, fieldWord{text: current.String()})
+				current.Reset()
+				inWord = false
+			}
+			quoted = true
+
+		case (r == ' ' || r == '\t') && !quoted:
+			if inWord {
+				words = append(words, fieldWord{text: current.String()})
+				current.Reset()
+				inWord = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inWord = !quoted
+		}
+	}
+
+	// An unterminated quote runs to the end of the instruction
+	if quoted || inWord {
+		words = append(words, fieldWord{text: current.String(), quoted: quoted})
+	}
+
+	return words
+}
+
+func (w fieldWord) isSwitch() bool {
+	return !w.quoted && len(w.text) > 1 && w.text[0] == '\\'
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseFieldInstruction(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+	}{
+		{name: "plain", field: `{\*\fldinst HYPERLINK "http://x"}`},
+		{name: "formatted", field: `{\*\fldinst {\rtlch\fcs1 \af0 HYPERLINK "http://x"}}`},
+		{name: "field type", field: `{\*\fldinst {\*\fldtype foo} HYPERLINK "http://x"}`},
+		{name: "data field", field: `{\*\fldinst HYPERLINK "http://x" {\*\datafield 00d0c9ea79f9bace118c8200aa004ba90b02000000}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := parseWithTimeout(t, stringParser(`{\rtf1\ansi\pard{\field`+test.field+`{\fldrslt link}}\par}`))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var field Field
+			for _, op := range ops {
+				if f, ok := op.(Field); ok {
+					field = f
+				}
+			}
+			if field.Instruction() != `HYPERLINK "http://x"` {
+				t.Errorf("instruction = %q", field.Instruction())
+			}
+			if field.FieldKind() != FieldHyperlink || field.Argument() != "http://x" {
+				t.Errorf("field %v with argument %q, want a link to http://x", field.FieldKind(), field.Argument())
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"rtf-parser/lexer"
+)
+
+// parseInfo parses the text and time groups and the statistics of an \info
+// group, up to its closing bracket which is left to close the group.
+func parseInfo(parser *Parser, word ControlWord) (Entity, error) {
+	info := DocumentInfo{
+		ControlWord: word,
+	}
+
+parseProperties:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseProperties
+
+		case lexer.TokenOpenBracket:
+			name := parser.peekGroupWord()
+			parser.consume()
+
+			if field := info.textField(name); field != nil {
+				text, err := parser.parseGroupText()
+				if err != nil {
+					return DocumentInfo{}, err
+				}
+				*field = strings.TrimSpace(text)
+				continue
+			}
+
+			if field := info.timeField(name); field != nil {
+				t, err := parseInfoTime(parser)
+				if err != nil {
+					return DocumentInfo{}, err
+				}
+				*field = t
+				continue
+			}
+
+			// Word writes the statistics in groups like {\nofpages3}
+			if field := info.countField(name); field != nil {
+				parser.consume()
+				_, arg, err := parser.parseGroupProperty()
+				if err != nil {
+					return DocumentInfo{}, err
+				}
+				*field = arg
+			}
+
+			parser.parseDestination()
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			name, arg, err := parser.parseGroupProperty()
+			if err != nil {
+				return DocumentInfo{}, err
+			}
+
+			if field := info.countField(name); field != nil {
+				*field = arg
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+
+	return info, nil
+}
+
+// textField returns the field holding the text of the named group, nil for
+// groups that are not text properties.
+func (info *DocumentInfo) textField(name string) *string {
+	switch name {
+	case "title":
+		return &info.title
+	case "subject":
+		return &info.subject
+	case "author":
+		return &info.author
+	case "operator":
+		return &info.operator
+	case "manager":
+		return &info.manager
+	case "company":
+		return &info.company
+	case "category":
+		return &info.category
+	case "keywords":
+		return &info.keywords
+	case "doccomm":
+		return &info.comment
+	}
+	return nil
+}
+
+// timeField returns the field holding the time of the named group, nil for
+// groups that are not times.
+func (info *DocumentInfo) timeField(name string) *time.Time {
+	switch name {
+	case "creatim":
+		return &info.created
+	case "revtim":
+		return &info.revised
+	case "printim":
+		return &info.printed
+	}
+	return nil
+}
+
+// countField returns the field holding the named statistic, nil for words
+// that are not statistics.
+func (info *DocumentInfo) countField(name string) *int {
+	switch name {
+	case "nofpages":
+		return &info.pages
+	case "nofwords":
+		return &info.words
+	case "nofchars":
+		return &info.characters
+	case "nofcharsws":
+		return &info.charactersWithSpaces
+	}
+	return nil
+}
+
+// parseInfoTime parses a time group like {\creatim\yr2024\mo3\dy1\hr9\min30},
+// the opening bracket being the current token. The closing bracket is
+// consumed. A group without a year gives the zero time.
+func parseInfoTime(parser *Parser) (time.Time, error) {
+	year, month, day, hour, minute, second := 0, 1, 1, 0, 0, 0
+
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF:
+			return time.Time{}, nil
+		case lexer.TokenCloseBracket:
+			parser.consume()
+			if year <= 0 {
+				return time.Time{}, nil
+			}
+			// Times are written in the local time of the author, which is
+			// not recorded
+			return time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC), nil
+
+		case lexer.TokenOpenBracket:
+			parser.consume()
+			parser.parseDestination()
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			name, arg, err := parser.parseGroupProperty()
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			switch name {
+			case "yr":
+				year = arg
+			case "mo":
+				month = arg
+			case "dy":
+				day = arg
+			case "hr":
+				hour = arg
+			case "min":
+				minute = arg
+			case "sec":
+				second = arg
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+}
+
+// FindDocumentInfo returns the document information of a parsed document, and
+// false when it has no \info group.
+func FindDocumentInfo(ops []Entity) (DocumentInfo, bool) {
+	for _, op := range ops {
+		if info, ok := op.(DocumentInfo); ok {
+			return info, true
+		}
+	}
+
+	return DocumentInfo{}, false
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseInfoStatistics(t *testing.T) {
+	tests := []struct {
+		name string
+		info string
+	}{
+		{name: "groups", info: `{\info{\title Report}{\nofpages3}{\nofwords120}{\nofchars700}{\nofcharsws815}}`},
+		{name: "words", info: `{\info{\title Report}\nofpages3\nofwords120\nofchars700\nofcharsws815}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := parseWithTimeout(t, stringParser(`{\rtf1\ansi`+test.info+`\pard a\par}`))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			info, ok := FindDocumentInfo(ops)
+			if !ok {
+				t.Fatalf("no document info")
+			}
+			if info.Title() != "Report" {
+				t.Errorf("title = %q, want %q", info.Title(), "Report")
+			}
+
+			got := []int{info.Pages(), info.Words(), info.Characters(), info.CharactersWithSpaces()}
+			want := []int{3, 120, 700, 815}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("statistics = %v, want %v", got, want)
+					break
+				}
+			}
+		})
+	}
+}
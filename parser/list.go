@@ -0,0 +1,313 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"rtf-parser/lexer"
+)
+
+const (
+	defaultListStartAt = 1
+)
+
+// parseListTable parses the \list groups of a \listtable, up to the closing
+// bracket of the table which is left to close the group.
+func parseListTable(parser *Parser, word ControlWord) (Entity, error) {
+	table := ListTable{
+		ControlWord: word,
+	}
+
+parseLists:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseLists
+
+		case lexer.TokenOpenBracket:
+			name := parser.peekGroupWord()
+			parser.consume()
+			if name != "list" {
+				parser.parseDestination()
+				continue
+			}
+
+			list, err := parseList(parser)
+			if err != nil {
+				return ListTable{}, err
+			}
+			table.lists = append(table.lists, list)
+
+		default:
+			parser.consume()
+		}
+	}
+
+	return table, nil
+}
+
+// parseList parses a \list group, the opening bracket being the current token.
+// The closing bracket is consumed.
+func parseList(parser *Parser) (Entity, error) {
+	list := List{
+		startToken: parser.current,
+	}
+
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF:
+			return list, nil
+		case lexer.TokenCloseBracket:
+			parser.consume()
+			return list, nil
+
+		case lexer.TokenOpenBracket:
+			name := parser.peekGroupWord()
+			parser.consume()
+			if name != "listlevel" {
+				parser.parseDestination()
+				continue
+			}
+
+			level, err := parseListLevel(parser)
+			if err != nil {
+				return List{}, err
+			}
+			list.levels = append(list.levels, level)
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			name, arg, err := parser.parseGroupProperty()
+			if err != nil {
+				return List{}, err
+			}
+
+			if name == "listid" {
+				list.id = arg
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+}
+
+// parseListLevel parses a \listlevel group, the opening bracket being the
+// current token. The closing bracket is consumed.
+func parseListLevel(parser *Parser) (Entity, error) {
+	level := ListLevel{
+		startToken: parser.current,
+		startAt:    defaultListStartAt,
+	}
+
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF:
+			return level, nil
+		case lexer.TokenCloseBracket:
+			parser.consume()
+			return level, nil
+
+		case lexer.TokenOpenBracket:
+			name := parser.peekGroupWord()
+			parser.consume()
+			if name != "leveltext" {
+				parser.parseDestination()
+				continue
+			}
+
+			text, err := parser.parseGroupText()
+			if err != nil {
+				return ListLevel{}, err
+			}
+
+			// The first character is the length of the text
+			_, size := utf8.DecodeRuneInString(text)
+			level.text = strings.TrimSuffix(text[size:], ";")
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			name, arg, err := parser.parseGroupProperty()
+			if err != nil {
+				return ListLevel{}, err
+			}
+
+			switch name {
+			case "levelnfc", "levelnfcn":
+				format, exist := listNumberFormatLookup[arg]
+				if !exist {
+					format = ListNumberDecimal
+				}
+				level.numberFormat = format
+			case "levelstartat":
+				level.startAt = arg
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+}
+
+// parseListOverrideTable parses the \listoverride groups of a
+// \listoverridetable, up to the closing bracket of the table which is left to
+// close the group.
+func parseListOverrideTable(parser *Parser, word ControlWord) (Entity, error) {
+	table := ListOverrideTable{
+		ControlWord: word,
+	}
+
+parseOverrides:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseOverrides
+
+		case lexer.TokenOpenBracket:
+			name := parser.peekGroupWord()
+			parser.consume()
+			if name != "listoverride" {
+				parser.parseDestination()
+				continue
+			}
+
+			override, err := parseListOverride(parser)
+			if err != nil {
+				return ListOverrideTable{}, err
+			}
+			table.overrides = append(table.overrides, override)
+
+		default:
+			parser.consume()
+		}
+	}
+
+	return table, nil
+}
+
+// parseListOverride parses a \listoverride group, the opening bracket being
+// the current token. The closing bracket is consumed.
+func parseListOverride(parser *Parser) (Entity, error) {
+	override := ListOverride{
+		startToken: parser.current,
+	}
+
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF:
+			return override, nil
+		case lexer.TokenCloseBracket:
+			parser.consume()
+			return override, nil
+
+		case lexer.TokenOpenBracket:
+			// \lfolevel overrides are not supported
+			parser.consume()
+			parser.parseDestination()
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			name, arg, err := parser.parseGroupProperty()
+			if err != nil {
+				return ListOverride{}, err
+			}
+
+			switch name {
+			case "listid":
+				override.listID = arg
+			case "ls":
+				override.index = arg
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+}
+
+// parseParagraphNumbering parses the content of a Word 6 \pn group up to its
+// closing bracket, which is left to close the group.
+func parseParagraphNumbering(parser *Parser, word ControlWord) (Entity, error) {
+	numbering := ParagraphNumbering{
+		ControlWord: word,
+		startAt:     defaultListStartAt,
+	}
+
+parseProperties:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseProperties
+
+		case lexer.TokenOpenBracket:
+			name := parser.peekGroupWord()
+			parser.consume()
+			if name != "pntxtb" && name != "pntxta" {
+				parser.parseDestination()
+				continue
+			}
+
+			text, err := parser.parseGroupText()
+			if err != nil {
+				return ParagraphNumbering{}, err
+			}
+			if name == "pntxtb" {
+				numbering.textBefore = text
+			} else {
+				numbering.textAfter = text
+			}
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			name, arg, err := parser.parseGroupProperty()
+			if err != nil {
+				return ParagraphNumbering{}, err
+			}
+
+			if format, exist := paragraphNumberFormatLookup[name]; exist {
+				numbering.numberFormat = format
+			}
+
+			switch name {
+			case "pnlvl":
+				// Outline levels start at 1
+				numbering.level = max(arg-1, 0)
+			case "pnstart":
+				numbering.startAt = arg
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+
+	return numbering, nil
+}
+
+// parseGroupProperty parses a control word within a definition group and its
+// optional parameter, -1 when absent. The backslash is the current token.
+func (parser *Parser) parseGroupProperty() (string, int, error) {
+	if err := parser.expectNext(lexer.TokenString); err != nil {
+		return "", 0, err
+	}
+
+	name := parser.current.Text()
+	arg, err := parser.parseOptionalNumber()
+	if err != nil {
+		return "", 0, err
+	}
+
+	return name, arg, nil
+}
@@ -0,0 +1,1387 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"rtf-parser/lexer"
+)
+
+const (
+	EntityKindInvalid EntityKind = iota
+	EntityKindControlGroup
+	EntityKindControlWord
+	EntityKindControlSymbol
+	EntityKindCharacterSet
+	EntityKindFontTable
+	EntityKindFontTableEntry
+	EntityKindColorTable
+	EntityKindColorTableEntry
+	EntityKindColorComponent
+	EntityKindTextFormat
+	EntityKindText
+	EntityKindUnicode
+	EntityKindDestination
+	EntityKindTableRow
+	EntityKindTableCell
+	EntityKindPicture
+	EntityKindField
+	EntityKindListTable
+	EntityKindList
+	EntityKindListLevel
+	EntityKindListOverrideTable
+	EntityKindListOverride
+	EntityKindParagraphNumbering
+	EntityKindStyleSheet
+	EntityKindStyle
+	EntityKindDocumentInfo
+)
+
+var (
+	entityKindStr = map[EntityKind]string{
+		EntityKindInvalid:            "Invalid",
+		EntityKindControlGroup:       "Control Group",
+		EntityKindControlWord:        "Control Word",
+		EntityKindControlSymbol:      "Control Symbol",
+		EntityKindCharacterSet:       "Character Set",
+		EntityKindFontTable:          "Font Table",
+		EntityKindFontTableEntry:     "Font Table Entry",
+		EntityKindColorTable:         "Color Table",
+		EntityKindColorComponent:     "Color Component",
+		EntityKindTextFormat:         "Text Format",
+		EntityKindText:               "Text",
+		EntityKindUnicode:            "Unicode",
+		EntityKindDestination:        "Destination",
+		EntityKindTableRow:           "Table Row",
+		EntityKindTableCell:          "Table Cell",
+		EntityKindPicture:            "Picture",
+		EntityKindField:              "Field",
+		EntityKindListTable:          "List Table",
+		EntityKindList:               "List",
+		EntityKindListLevel:          "List Level",
+		EntityKindListOverrideTable:  "List Override Table",
+		EntityKindListOverride:       "List Override",
+		EntityKindParagraphNumbering: "Paragraph Numbering",
+		EntityKindStyleSheet:         "Style Sheet",
+		EntityKindStyle:              "Style",
+		EntityKindDocumentInfo:       "Document Info",
+	}
+)
+
+const (
+	ControlGroupKindBegin ControlGroupKind = iota
+	ControlGroupKindEnd
+)
+
+var (
+	controlGroupKindStr = map[ControlGroupKind]string{
+		ControlGroupKindBegin: "Begin",
+		ControlGroupKindEnd:   "End",
+	}
+)
+
+const (
+	CharacterSetInvalid CharacterSetKind = iota
+	CharacterSetANSI
+	CharacterSetANSICPG
+	CharacterSetMAC
+	CharacterSetPC
+	CharacterSetPCA
+	CharacterSetFBIDIS
+)
+
+var (
+	characterSetKindLookup = map[string]CharacterSetKind{
+		"ansi":    CharacterSetANSI,
+		"ansicpg": CharacterSetANSICPG,
+		"mac":     CharacterSetMAC,
+		"pc":      CharacterSetPC,
+		"pca":     CharacterSetPCA,
+		"fbidis":  CharacterSetFBIDIS,
+	}
+
+	characterSetKindStr = map[CharacterSetKind]string{
+		CharacterSetANSI:    "ansi",
+		CharacterSetANSICPG: "ansicpg",
+		CharacterSetMAC:     "mac",
+		CharacterSetPC:      "pc",
+		CharacterSetPCA:     "pca",
+		CharacterSetFBIDIS:  "fbidi",
+	}
+)
+
+const (
+	TextFormatColor TextFormatKind = iota
+	TextFormatItalic
+	TextFormatStrike
+	TextFormatFontIndex
+	TextFormatFontSize
+	TextFormatFontWeightBold
+	TextFormatAlignCenter
+	TextFormatAlignJustify
+	TextFormatAlignRight
+	TextFormatLeftIndent
+	TextFormatFirstIndent
+	TextFormatSpaceBefore
+	TextFormatSpaceAfter
+	TextFormatSpaceBeforeAuto
+	TextFormatSpaceAfterAuto
+	TextFormatLineSpacing
+	TextFormatLineSpacingMultiple
+	TextFormatTab
+	TextFormatLineBreak
+	TextFormatTabPosition
+	TextFormatBarTabPosition
+	TextFormatTabAlignCenter
+	TextFormatTabAlignRight
+	TextFormatTabAlignDecimal
+	TextFormatTabLeaderDot
+	TextFormatTabLeaderMiddleDot
+	TextFormatTabLeaderHyphen
+	TextFormatTabLeaderUnderline
+	TextFormatTabLeaderThick
+	TextFormatTabLeaderEqual
+	TextFormatParagraphClear
+	TextFormatParagraphEnd
+	TextFormatUnderline
+	TextFormatUnderlineDotted
+	TextFormatUnderlineDashed
+	TextFormatUnderlineDouble
+	TextFormatUnderlineWave
+	TextFormatUnderlineWords
+	TextFormatUnderlineNone
+	TextFormatUnderlineColor
+	TextFormatSuperscript
+	TextFormatSubscript
+	TextFormatNoSuperSub
+	TextFormatBaselineUp
+	TextFormatBaselineDown
+	TextFormatHighlight
+	TextFormatBackgroundColor
+	TextFormatParagraphBackgroundColor
+	TextFormatInTable
+	TextFormatTableDepth
+	TextFormatListIndex
+	TextFormatListLevel
+	TextFormatParagraphStyle
+	TextFormatCharacterStyle
+	TextFormatSectionStyle
+	TextFormatCellEnd
+	TextFormatNestedCellEnd
+	TextFormatRowEnd
+	TextFormatNestedRowEnd
+	TextFormatPlain
+)
+
+var (
+	textFormatKindLookup = map[string]TextFormatKind{
+		"cf":     TextFormatColor,
+		"i":      TextFormatItalic,
+		"strike": TextFormatStrike,
+		"f":      TextFormatFontIndex,
+		"fs":     TextFormatFontSize,
+		"b":      TextFormatFontWeightBold,
+
+		"qc": TextFormatAlignCenter,
+		"qj": TextFormatAlignJustify,
+		"qr": TextFormatAlignRight,
+
+		"li":     TextFormatLeftIndent,
+		"fi":     TextFormatFirstIndent,
+		"sb":     TextFormatSpaceBefore,
+		"sa":     TextFormatSpaceAfter,
+		"sbauto": TextFormatSpaceBeforeAuto,
+		"saauto": TextFormatSpaceAfterAuto,
+		"sl":     TextFormatLineSpacing,
+		"slmult": TextFormatLineSpacingMultiple,
+		"tab":    TextFormatTab,
+		"line":   TextFormatLineBreak,
+		"tx":     TextFormatTabPosition,
+		"tb":     TextFormatBarTabPosition,
+		"tqc":    TextFormatTabAlignCenter,
+		"tqr":    TextFormatTabAlignRight,
+		"tqdec":  TextFormatTabAlignDecimal,
+		"tldot":  TextFormatTabLeaderDot,
+		"tlmdot": TextFormatTabLeaderMiddleDot,
+		"tlhyph": TextFormatTabLeaderHyphen,
+		"tlul":   TextFormatTabLeaderUnderline,
+		"tlth":   TextFormatTabLeaderThick,
+		"tleq":   TextFormatTabLeaderEqual,
+
+		"pard": TextFormatParagraphClear,
+		"par":  TextFormatParagraphEnd,
+
+		"ul":     TextFormatUnderline,
+		"uld":    TextFormatUnderlineDotted,
+		"uldash": TextFormatUnderlineDashed,
+		"uldb":   TextFormatUnderlineDouble,
+		"ulwave": TextFormatUnderlineWave,
+		"ulw":    TextFormatUnderlineWords,
+		"ulnone": TextFormatUnderlineNone,
+		"ulc":    TextFormatUnderlineColor,
+
+		"super":      TextFormatSuperscript,
+		"sub":        TextFormatSubscript,
+		"nosupersub": TextFormatNoSuperSub,
+		"up":         TextFormatBaselineUp,
+		"dn":         TextFormatBaselineDown,
+
+		"highlight": TextFormatHighlight,
+		"cb":        TextFormatBackgroundColor,
+		"chcbpat":   TextFormatBackgroundColor,
+		"cbpat":     TextFormatParagraphBackgroundColor,
+
+		"intbl":    TextFormatInTable,
+		"itap":     TextFormatTableDepth,
+		"ls":       TextFormatListIndex,
+		"ilvl":     TextFormatListLevel,
+		"s":        TextFormatParagraphStyle,
+		"cs":       TextFormatCharacterStyle,
+		"ds":       TextFormatSectionStyle,
+		"cell":     TextFormatCellEnd,
+		"nestcell": TextFormatNestedCellEnd,
+		"row":      TextFormatRowEnd,
+		"nestrow":  TextFormatNestedRowEnd,
+
+		"plain": TextFormatPlain,
+	}
+
+	textFormatKindStr = map[TextFormatKind]string{
+		TextFormatColor:                    "Color",
+		TextFormatItalic:                   "Italic",
+		TextFormatStrike:                   "Strike",
+		TextFormatFontIndex:                "Font",
+		TextFormatFontSize:                 "Font Size",
+		TextFormatFontWeightBold:           "Font Bold",
+		TextFormatAlignCenter:              "Align Center",
+		TextFormatAlignJustify:             "Align Justify",
+		TextFormatAlignRight:               "Align Right",
+		TextFormatLeftIndent:               "Left Indent",
+		TextFormatFirstIndent:              "First Indent",
+		TextFormatSpaceBefore:              "Space Before",
+		TextFormatSpaceAfter:               "Space After",
+		TextFormatSpaceBeforeAuto:          "Space Before Auto",
+		TextFormatSpaceAfterAuto:           "Space After Auto",
+		TextFormatLineSpacing:              "Line Spacing",
+		TextFormatLineSpacingMultiple:      "Line Spacing Multiple",
+		TextFormatTab:                      "Tab",
+		TextFormatLineBreak:                "Line Break",
+		TextFormatTabPosition:              "Tab Position",
+		TextFormatBarTabPosition:           "Bar Tab Position",
+		TextFormatTabAlignCenter:           "Tab Align Center",
+		TextFormatTabAlignRight:            "Tab Align Right",
+		TextFormatTabAlignDecimal:          "Tab Align Decimal",
+		TextFormatTabLeaderDot:             "Tab Leader Dot",
+		TextFormatTabLeaderMiddleDot:       "Tab Leader Middle Dot",
+		TextFormatTabLeaderHyphen:          "Tab Leader Hyphen",
+		TextFormatTabLeaderUnderline:       "Tab Leader Underline",
+		TextFormatTabLeaderThick:           "Tab Leader Thick",
+		TextFormatTabLeaderEqual:           "Tab Leader Equal",
+		TextFormatParagraphClear:           "Paragraph Clear",
+		TextFormatParagraphEnd:             "Paragraph End",
+		TextFormatUnderline:                "Underline",
+		TextFormatUnderlineDotted:          "Underline Dotted",
+		TextFormatUnderlineDashed:          "Underline Dashed",
+		TextFormatUnderlineDouble:          "Underline Double",
+		TextFormatUnderlineWave:            "Underline Wave",
+		TextFormatUnderlineWords:           "Underline Words",
+		TextFormatUnderlineNone:            "Underline None",
+		TextFormatUnderlineColor:           "Underline Color",
+		TextFormatSuperscript:              "Superscript",
+		TextFormatSubscript:                "Subscript",
+		TextFormatNoSuperSub:               "No Superscript/Subscript",
+		TextFormatBaselineUp:               "Baseline Up",
+		TextFormatBaselineDown:             "Baseline Down",
+		TextFormatHighlight:                "Highlight",
+		TextFormatBackgroundColor:          "Background Color",
+		TextFormatParagraphBackgroundColor: "Paragraph Background Color",
+		TextFormatInTable:                  "In Table",
+		TextFormatTableDepth:               "Table Depth",
+		TextFormatListIndex:                "List Index",
+		TextFormatListLevel:                "List Level",
+		TextFormatParagraphStyle:           "Paragraph Style",
+		TextFormatCharacterStyle:           "Character Style",
+		TextFormatSectionStyle:             "Section Style",
+		TextFormatCellEnd:                  "Cell End",
+		TextFormatNestedCellEnd:            "Nested Cell End",
+		TextFormatRowEnd:                   "Row End",
+		TextFormatNestedRowEnd:             "Nested Row End",
+		TextFormatPlain:                    "Plain",
+	}
+)
+
+const (
+	TableRowAlignLeft TableRowAlignKind = iota
+	TableRowAlignCenter
+	TableRowAlignRight
+)
+
+const (
+	TableCellAlignTop TableCellAlignKind = iota
+	TableCellAlignCenter
+	TableCellAlignBottom
+)
+
+const (
+	TableCellMergeNone TableCellMergeKind = iota
+	// First cell of a merged range
+	TableCellMergeFirst
+	// Cell merged into the previous one
+	TableCellMergeContinue
+)
+
+const (
+	TableBorderTop TableBorderSide = iota
+	TableBorderLeft
+	TableBorderBottom
+	TableBorderRight
+	TableBorderMAX
+)
+
+const (
+	BorderStyleNone BorderStyleKind = iota
+	BorderStyleSingle
+	BorderStyleThick
+	BorderStyleDouble
+	BorderStyleDotted
+	BorderStyleDashed
+	BorderStyleHairline
+)
+
+const (
+	// A picture format the parser does not decode (\macpict, \dibitmap, ...)
+	PictureFormatUnknown PictureFormatKind = iota
+	PictureFormatPNG
+	PictureFormatJPEG
+	PictureFormatEMF
+	PictureFormatWMF
+)
+
+var (
+	pictureFormatKindLookup = map[string]PictureFormatKind{
+		"pngblip":   PictureFormatPNG,
+		"jpegblip":  PictureFormatJPEG,
+		"emfblip":   PictureFormatEMF,
+		"wmetafile": PictureFormatWMF,
+	}
+
+	pictureFormatKindStr = map[PictureFormatKind]string{
+		PictureFormatUnknown: "Unknown",
+		PictureFormatPNG:     "PNG",
+		PictureFormatJPEG:    "JPEG",
+		PictureFormatEMF:     "EMF",
+		PictureFormatWMF:     "WMF",
+	}
+)
+
+const (
+	// A field the parser does not interpret, only its result text is used
+	FieldUnknown FieldKind = iota
+	FieldHyperlink
+	FieldPage
+	FieldDate
+	FieldTOC
+	FieldMergeField
+)
+
+var (
+	fieldKindLookup = map[string]FieldKind{
+		"HYPERLINK":  FieldHyperlink,
+		"PAGE":       FieldPage,
+		"DATE":       FieldDate,
+		"TOC":        FieldTOC,
+		"MERGEFIELD": FieldMergeField,
+	}
+
+	fieldKindStr = map[FieldKind]string{
+		FieldUnknown:    "Unknown",
+		FieldHyperlink:  "Hyperlink",
+		FieldPage:       "Page",
+		FieldDate:       "Date",
+		FieldTOC:        "TOC",
+		FieldMergeField: "Merge Field",
+	}
+)
+
+const (
+	ListNumberDecimal ListNumberFormat = iota
+	ListNumberUpperRoman
+	ListNumberLowerRoman
+	ListNumberUpperAlpha
+	ListNumberLowerAlpha
+	ListNumberBullet
+	ListNumberNone
+)
+
+var (
+	// \levelnfc values, the formats missing from it are numbered in decimal
+	listNumberFormatLookup = map[int]ListNumberFormat{
+		0:   ListNumberDecimal,
+		1:   ListNumberUpperRoman,
+		2:   ListNumberLowerRoman,
+		3:   ListNumberUpperAlpha,
+		4:   ListNumberLowerAlpha,
+		23:  ListNumberBullet,
+		255: ListNumberNone,
+	}
+
+	// Number formats of the Word 6 \pn words
+	paragraphNumberFormatLookup = map[string]ListNumberFormat{
+		"pndec":    ListNumberDecimal,
+		"pnucrm":   ListNumberUpperRoman,
+		"pnlcrm":   ListNumberLowerRoman,
+		"pnucltr":  ListNumberUpperAlpha,
+		"pnlcltr":  ListNumberLowerAlpha,
+		"pnlvlblt": ListNumberBullet,
+	}
+
+	listNumberFormatStr = map[ListNumberFormat]string{
+		ListNumberDecimal:    "Decimal",
+		ListNumberUpperRoman: "Upper Roman",
+		ListNumberLowerRoman: "Lower Roman",
+		ListNumberUpperAlpha: "Upper Alpha",
+		ListNumberLowerAlpha: "Lower Alpha",
+		ListNumberBullet:     "Bullet",
+		ListNumberNone:       "None",
+	}
+)
+
+const (
+	StyleKindParagraph StyleKind = iota
+	StyleKindCharacter
+	StyleKindSection
+	StyleKindTable
+)
+
+var (
+	// Words giving the kind and index of a style
+	styleKindLookup = map[string]StyleKind{
+		"s":  StyleKindParagraph,
+		"cs": StyleKindCharacter,
+		"ds": StyleKindSection,
+		"ts": StyleKindTable,
+	}
+
+	styleKindStr = map[StyleKind]string{
+		StyleKindParagraph: "Paragraph",
+		StyleKindCharacter: "Character",
+		StyleKindSection:   "Section",
+		StyleKindTable:     "Table",
+	}
+)
+
+var (
+	borderStyleKindLookup = map[string]BorderStyleKind{
+		"brdrnone": BorderStyleNone,
+		"brdrnil":  BorderStyleNone,
+		"brdrs":    BorderStyleSingle,
+		"brdrsh":   BorderStyleSingle,
+		"brdrth":   BorderStyleThick,
+		"brdrdb":   BorderStyleDouble,
+		"brdrdot":  BorderStyleDotted,
+		"brdrdash": BorderStyleDashed,
+		"brdrhair": BorderStyleHairline,
+	}
+
+	tableBorderSideLookup = map[string]TableBorderSide{
+		"clbrdrt": TableBorderTop,
+		"clbrdrl": TableBorderLeft,
+		"clbrdrb": TableBorderBottom,
+		"clbrdrr": TableBorderRight,
+	}
+)
+
+type (
+	EntityKind int
+
+	Entity interface {
+		Kind() EntityKind
+		Token() lexer.Token
+	}
+
+	ControlGroupKind   uint8
+	CharacterSetKind   uint8
+	TextFormatKind     uint8
+	TableRowAlignKind  uint8
+	TableCellAlignKind uint8
+	TableCellMergeKind uint8
+	TableBorderSide    uint8
+	BorderStyleKind    uint8
+	PictureFormatKind  uint8
+	FieldKind          uint8
+	ListNumberFormat   uint8
+	StyleKind          uint8
+
+	ControlGroup struct {
+		token     lexer.Token
+		groupKind ControlGroupKind
+	}
+
+	ControlWord struct {
+		token     lexer.Token
+		wordToken lexer.Token
+	}
+
+	// ControlSymbol is a backslash followed by a single non-alphabetic
+	// character. Symbols standing for text (\{, \~, ...) are folded into Text
+	// entities instead.
+	ControlSymbol struct {
+		token  lexer.Token
+		symbol byte
+	}
+
+	CharacterSet struct {
+		ControlWord
+		setKind  CharacterSetKind
+		codePage int
+	}
+
+	FontTable struct {
+		ControlWord
+		fonts []Entity
+	}
+
+	FontTableEntry struct {
+		startToken      lexer.Token
+		fontName        Text
+		index           int
+		charset         int
+		defaultFallback bool
+	}
+
+	ColorTable struct {
+		ControlWord
+		colors []Entity
+	}
+
+	ColorTableEntry struct {
+		startToken lexer.Token
+		channels   [4]Entity
+	}
+
+	ColorComponent struct {
+		ControlWord
+		value uint8
+	}
+
+	TextFormat struct {
+		ControlWord
+		formatKind TextFormatKind
+		arg        int
+	}
+
+	// Unicode is a \uN escape. The skip count is the \ucN value in effect
+	// for the group it appears in, the number of fallback characters that
+	// were dropped after it.
+	Unicode struct {
+		ControlWord
+		value rune
+		skip  int
+	}
+
+	// TableRow is the definition of a table row started by \trowd: the row
+	// properties and one TableCell per \cellx. Measures are in twips.
+	TableRow struct {
+		ControlWord
+		left      int
+		gap       int
+		height    int
+		alignKind TableRowAlignKind
+		header    bool
+		cells     []Entity
+	}
+
+	// TableCell is the definition of a cell within a TableRow, ending with
+	// the \cellx boundary.
+	TableCell struct {
+		startToken    lexer.Token
+		boundary      int
+		borders       [TableBorderMAX]TableBorder
+		shading       int
+		alignKind     TableCellAlignKind
+		mergeKind     TableCellMergeKind
+		verticalMerge TableCellMergeKind
+	}
+
+	TableBorder struct {
+		styleKind BorderStyleKind
+		width     int
+		color     int
+	}
+
+	// Picture is the content of a \pict group: the image data, decoded from
+	// hex or \bin, and its size. The native size is in pixels for bitmaps
+	// and in hundredths of a millimeter for metafiles, the goal size in twips.
+	Picture struct {
+		ControlWord
+		formatKind PictureFormatKind
+		width      int
+		height     int
+		goalWidth  int
+		goalHeight int
+		scaleX     int
+		scaleY     int
+		data       []byte
+	}
+
+	// Field is a \field with its decoded \fldinst instruction. The \fldrslt
+	// group that follows holds the last computed result and is parsed as
+	// part of the document.
+	Field struct {
+		ControlWord
+		fieldKind   FieldKind
+		instruction string
+		argument    string
+		switches    []FieldSwitch
+	}
+
+	// FieldSwitch is a switch of a field instruction, like \l "anchor" in a
+	// HYPERLINK field. Name holds the switch without its backslash.
+	FieldSwitch struct {
+		Name string
+		Arg  string
+	}
+
+	// ListTable holds the list definitions of \listtable
+	ListTable struct {
+		ControlWord
+		lists []Entity
+	}
+
+	// List is a \list definition with one ListLevel per nesting level
+	List struct {
+		startToken lexer.Token
+		id         int
+		levels     []Entity
+	}
+
+	ListLevel struct {
+		startToken   lexer.Token
+		numberFormat ListNumberFormat
+		startAt      int
+		// Decoded \leveltext: the length prefix is dropped and the level
+		// numbers are left as the characters \x00 to \x08.
+		text string
+	}
+
+	// ListOverrideTable holds the \listoverride entries of
+	// \listoverridetable, which paragraphs refer to with \lsN.
+	ListOverrideTable struct {
+		ControlWord
+		overrides []Entity
+	}
+
+	ListOverride struct {
+		startToken lexer.Token
+		listID     int
+		index      int
+	}
+
+	// ParagraphNumbering is a Word 6 \pn numbering of the paragraph it
+	// appears in. Levels start at 0 like \ilvl.
+	ParagraphNumbering struct {
+		ControlWord
+		level        int
+		numberFormat ListNumberFormat
+		startAt      int
+		textBefore   string
+		textAfter    string
+	}
+
+	// StyleSheet holds the styles defined by \stylesheet
+	StyleSheet struct {
+		ControlWord
+		styles []Entity
+	}
+
+	// Style is a style of the stylesheet. Its formats are the TextFormat
+	// entities of its definition, not including the inherited ones.
+	Style struct {
+		startToken lexer.Token
+		styleKind  StyleKind
+		index      int
+		basedOn    int
+		next       int
+		additive   bool
+		name       string
+		formats    []Entity
+	}
+
+	// DocumentInfo holds the metadata of the \info group. Times are zero when
+	// the document does not record them, counts are 0.
+	DocumentInfo struct {
+		ControlWord
+		title    string
+		subject  string
+		author   string
+		operator string
+		manager  string
+		company  string
+		category string
+		keywords string
+		comment  string
+
+		created time.Time
+		revised time.Time
+		printed time.Time
+
+		pages                int
+		words                int
+		characters           int
+		charactersWithSpaces int
+	}
+
+	// Destination is a group the parser skipped, kept with its raw source
+	// (brackets included) when ParsingOptions.KeepDestinations is set.
+	Destination struct {
+		startToken lexer.Token
+		wordToken  lexer.Token
+		ignorable  bool
+		raw        string
+	}
+
+	Text struct {
+		leadingToken lexer.Token
+		tokens       []lexer.Token
+		value        string
+	}
+)
+
+func (c ControlGroup) Kind() EntityKind {
+	return EntityKindControlGroup
+}
+
+func (c ControlGroup) Token() lexer.Token {
+	return c.token
+}
+
+func (c ControlWord) Kind() EntityKind {
+	return EntityKindControlWord
+}
+
+func (c ControlWord) Token() lexer.Token {
+	return c.token
+}
+
+func (c ControlSymbol) Kind() EntityKind {
+	return EntityKindControlSymbol
+}
+
+func (c ControlSymbol) Token() lexer.Token {
+	return c.token
+}
+
+func (c CharacterSet) Kind() EntityKind {
+	return EntityKindCharacterSet
+}
+
+func (c CharacterSet) Token() lexer.Token {
+	return c.token
+}
+
+func (f FontTable) Kind() EntityKind {
+	return EntityKindFontTable
+}
+
+func (f FontTable) Token() lexer.Token {
+	return f.token
+}
+
+func (f FontTableEntry) Kind() EntityKind {
+	return EntityKindFontTableEntry
+}
+
+func (f FontTableEntry) Token() lexer.Token {
+	return f.startToken
+}
+
+func (c ColorTable) Kind() EntityKind {
+	return EntityKindColorTable
+}
+
+func (c ColorTable) Token() lexer.Token {
+	return c.token
+}
+
+func (c ColorTableEntry) Kind() EntityKind {
+	return EntityKindColorTableEntry
+}
+
+func (c ColorTableEntry) Token() lexer.Token {
+	return c.startToken
+}
+
+func (c ColorComponent) Kind() EntityKind {
+	return EntityKindColorComponent
+}
+
+func (c ColorComponent) Token() lexer.Token {
+	return c.token
+}
+
+func (t Text) Kind() EntityKind {
+	return EntityKindText
+}
+
+func (c TextFormat) Kind() EntityKind {
+	return EntityKindTextFormat
+}
+
+func (c TextFormat) Token() lexer.Token {
+	return c.token
+}
+
+func (u Unicode) Kind() EntityKind {
+	return EntityKindUnicode
+}
+
+func (u Unicode) Token() lexer.Token {
+	return u.token
+}
+
+func (t TableRow) Kind() EntityKind {
+	return EntityKindTableRow
+}
+
+func (t TableRow) Token() lexer.Token {
+	return t.token
+}
+
+func (p Picture) Kind() EntityKind {
+	return EntityKindPicture
+}
+
+func (p Picture) Token() lexer.Token {
+	return p.token
+}
+
+func (f Field) Kind() EntityKind {
+	return EntityKindField
+}
+
+func (f Field) Token() lexer.Token {
+	return f.token
+}
+
+func (l ListTable) Kind() EntityKind {
+	return EntityKindListTable
+}
+
+func (l ListTable) Token() lexer.Token {
+	return l.token
+}
+
+func (l List) Kind() EntityKind {
+	return EntityKindList
+}
+
+func (l List) Token() lexer.Token {
+	return l.startToken
+}
+
+func (l ListLevel) Kind() EntityKind {
+	return EntityKindListLevel
+}
+
+func (l ListLevel) Token() lexer.Token {
+	return l.startToken
+}
+
+func (l ListOverrideTable) Kind() EntityKind {
+	return EntityKindListOverrideTable
+}
+
+func (l ListOverrideTable) Token() lexer.Token {
+	return l.token
+}
+
+func (l ListOverride) Kind() EntityKind {
+	return EntityKindListOverride
+}
+
+func (l ListOverride) Token() lexer.Token {
+	return l.startToken
+}
+
+func (n ParagraphNumbering) Kind() EntityKind {
+	return EntityKindParagraphNumbering
+}
+
+func (n ParagraphNumbering) Token() lexer.Token {
+	return n.token
+}
+
+func (s StyleSheet) Kind() EntityKind {
+	return EntityKindStyleSheet
+}
+
+func (s StyleSheet) Token() lexer.Token {
+	return s.token
+}
+
+func (i DocumentInfo) Kind() EntityKind {
+	return EntityKindDocumentInfo
+}
+
+func (i DocumentInfo) Token() lexer.Token {
+	return i.token
+}
+
+func (s Style) Kind() EntityKind {
+	return EntityKindStyle
+}
+
+func (s Style) Token() lexer.Token {
+	return s.startToken
+}
+
+func (t TableCell) Kind() EntityKind {
+	return EntityKindTableCell
+}
+
+func (t TableCell) Token() lexer.Token {
+	return t.startToken
+}
+
+func (d Destination) Kind() EntityKind {
+	return EntityKindDestination
+}
+
+func (d Destination) Token() lexer.Token {
+	return d.startToken
+}
+
+func (t Text) Token() lexer.Token {
+	return t.leadingToken
+}
+
+// String returns the decoded content of the text, with escapes already
+// resolved to UTF-8.
+func (t Text) String() string {
+	return t.value
+}
+
+func (t Text) writeToString(builder *strings.Builder) {
+	builder.WriteString(t.value)
+}
+
+func (t Text) Tokens() []lexer.Token {
+	return t.tokens
+}
+
+func (c ControlGroup) GroupKind() ControlGroupKind {
+	return c.groupKind
+}
+
+func (c ControlWord) WordToken() lexer.Token {
+	return c.wordToken
+}
+
+func (c ControlWord) Name() string {
+	return c.wordToken.Text()
+}
+
+func (c ControlSymbol) Symbol() byte {
+	return c.symbol
+}
+
+func (c CharacterSet) SetKind() CharacterSetKind {
+	return c.setKind
+}
+
+func (c CharacterSet) CodePage() int {
+	return c.codePage
+}
+
+func (f FontTable) Fonts() []Entity {
+	return f.fonts
+}
+
+func (f FontTableEntry) FontName() Text {
+	return f.fontName
+}
+
+func (f FontTableEntry) Index() int {
+	return f.index
+}
+
+func (f FontTableEntry) Charset() int {
+	return f.charset
+}
+
+func (f FontTableEntry) DefaultFallback() bool {
+	return f.defaultFallback
+}
+
+func (c ColorTable) Colors() []Entity {
+	return c.colors
+}
+
+func (c ColorTableEntry) Channels() [4]Entity {
+	return c.channels
+}
+
+func (c ColorComponent) Value() uint8 {
+	return c.value
+}
+
+// Value returns the UTF-16 code unit of the escape. Characters outside the
+// Basic Multilingual Plane are written as two escapes, one per surrogate.
+func (u Unicode) Value() rune {
+	return u.value
+}
+
+func (u Unicode) Skip() int {
+	return u.skip
+}
+
+// Left returns the position of the left edge of the row, relative to the
+// paragraph margin.
+func (t TableRow) Left() int {
+	return t.left
+}
+
+// Gap returns half the space between the text of two adjacent cells
+func (t TableRow) Gap() int {
+	return t.gap
+}
+
+// Height returns the height of the row: at least that tall when positive,
+// exactly that tall when negative and automatic when 0.
+func (t TableRow) Height() int {
+	return t.height
+}
+
+func (t TableRow) AlignKind() TableRowAlignKind {
+	return t.alignKind
+}
+
+// Header reports whether the row repeats at the top of each page
+func (t TableRow) Header() bool {
+	return t.header
+}
+
+func (t TableRow) Cells() []Entity {
+	return t.cells
+}
+
+// Boundary returns the position of the right edge of the cell, relative to
+// the paragraph margin.
+func (t TableCell) Boundary() int {
+	return t.boundary
+}
+
+func (t TableCell) Border(side TableBorderSide) TableBorder {
+	return t.borders[side]
+}
+
+// Shading returns the color table index of the cell background, 0 if none
+func (t TableCell) Shading() int {
+	return t.shading
+}
+
+func (t TableCell) AlignKind() TableCellAlignKind {
+	return t.alignKind
+}
+
+// MergeKind returns how the cell merges with its horizontal neighbours
+func (t TableCell) MergeKind() TableCellMergeKind {
+	return t.mergeKind
+}
+
+// VerticalMerge returns how the cell merges with the cells above and below
+func (t TableCell) VerticalMerge() TableCellMergeKind {
+	return t.verticalMerge
+}
+
+func (b TableBorder) StyleKind() BorderStyleKind {
+	return b.styleKind
+}
+
+// Width returns the width of the border in twips
+func (b TableBorder) Width() int {
+	return b.width
+}
+
+// Color returns the color table index of the border, 0 for automatic
+func (b TableBorder) Color() int {
+	return b.color
+}
+
+func (p Picture) FormatKind() PictureFormatKind {
+	return p.formatKind
+}
+
+func (p Picture) Width() int {
+	return p.width
+}
+
+func (p Picture) Height() int {
+	return p.height
+}
+
+// GoalWidth returns the desired width in twips, 0 if not given
+func (p Picture) GoalWidth() int {
+	return p.goalWidth
+}
+
+// GoalHeight returns the desired height in twips, 0 if not given
+func (p Picture) GoalHeight() int {
+	return p.goalHeight
+}
+
+// ScaleX returns the horizontal scaling in percent
+func (p Picture) ScaleX() int {
+	return p.scaleX
+}
+
+// ScaleY returns the vertical scaling in percent
+func (p Picture) ScaleY() int {
+	return p.scaleY
+}
+
+func (p Picture) Data() []byte {
+	return p.data
+}
+
+func (f Field) FieldKind() FieldKind {
+	return f.fieldKind
+}
+
+// Instruction returns the whole instruction text of the field
+func (f Field) Instruction() string {
+	return f.instruction
+}
+
+// Argument returns the first argument of the instruction, like the URL of a
+// HYPERLINK or the name of a MERGEFIELD, with its quotes removed.
+func (f Field) Argument() string {
+	return f.argument
+}
+
+func (f Field) Switches() []FieldSwitch {
+	return f.switches
+}
+
+// Switch returns the argument of the switch of the given name, and whether the
+// instruction has it.
+func (f Field) Switch(name string) (string, bool) {
+	for _, s := range f.switches {
+		if s.Name == name {
+			return s.Arg, true
+		}
+	}
+	return "", false
+}
+
+func (l ListTable) Lists() []Entity {
+	return l.lists
+}
+
+func (l List) ID() int {
+	return l.id
+}
+
+func (l List) Levels() []Entity {
+	return l.levels
+}
+
+func (l ListLevel) NumberFormat() ListNumberFormat {
+	return l.numberFormat
+}
+
+// StartAt returns the number of the first item of the level
+func (l ListLevel) StartAt() int {
+	return l.startAt
+}
+
+func (l ListLevel) Text() string {
+	return l.text
+}
+
+func (l ListOverrideTable) Overrides() []Entity {
+	return l.overrides
+}
+
+// ListID returns the id of the List the override refers to
+func (l ListOverride) ListID() int {
+	return l.listID
+}
+
+// Index returns the \lsN number paragraphs use to refer to the override
+func (l ListOverride) Index() int {
+	return l.index
+}
+
+func (n ParagraphNumbering) Level() int {
+	return n.level
+}
+
+func (n ParagraphNumbering) NumberFormat() ListNumberFormat {
+	return n.numberFormat
+}
+
+func (n ParagraphNumbering) StartAt() int {
+	return n.startAt
+}
+
+// TextBefore returns the \pntxtb text, which is the bullet of bulleted
+// paragraphs.
+func (n ParagraphNumbering) TextBefore() string {
+	return n.textBefore
+}
+
+func (n ParagraphNumbering) TextAfter() string {
+	return n.textAfter
+}
+
+func (s StyleSheet) Styles() []Entity {
+	return s.styles
+}
+
+func (s Style) StyleKind() StyleKind {
+	return s.styleKind
+}
+
+// Index returns the N of the \sN, \csN, \dsN or \tsN word referring to the
+// style
+func (s Style) Index() int {
+	return s.index
+}
+
+// BasedOn returns the index of the style this one inherits from, -1 if none
+func (s Style) BasedOn() int {
+	return s.basedOn
+}
+
+// Next returns the index of the style of the paragraph following one of this
+// style
+func (s Style) Next() int {
+	return s.next
+}
+
+// Additive reports whether a character style adds to the formatting of the
+// paragraph rather than replacing it
+func (s Style) Additive() bool {
+	return s.additive
+}
+
+func (s Style) Name() string {
+	return s.name
+}
+
+func (s Style) Formats() []Entity {
+	return s.formats
+}
+
+func (i DocumentInfo) Title() string {
+	return i.title
+}
+
+func (i DocumentInfo) Subject() string {
+	return i.subject
+}
+
+func (i DocumentInfo) Author() string {
+	return i.author
+}
+
+// Operator returns the name of the last person who edited the document
+func (i DocumentInfo) Operator() string {
+	return i.operator
+}
+
+func (i DocumentInfo) Manager() string {
+	return i.manager
+}
+
+func (i DocumentInfo) Company() string {
+	return i.company
+}
+
+func (i DocumentInfo) Category() string {
+	return i.category
+}
+
+// Keywords returns the keywords as written, usually separated by spaces or
+// commas
+func (i DocumentInfo) Keywords() string {
+	return i.keywords
+}
+
+func (i DocumentInfo) Comment() string {
+	return i.comment
+}
+
+// Created returns the creation time, zero when unknown
+func (i DocumentInfo) Created() time.Time {
+	return i.created
+}
+
+// Revised returns the time of the last revision, zero when unknown
+func (i DocumentInfo) Revised() time.Time {
+	return i.revised
+}
+
+// Printed returns the time the document was last printed, zero when unknown
+func (i DocumentInfo) Printed() time.Time {
+	return i.printed
+}
+
+func (i DocumentInfo) Pages() int {
+	return i.pages
+}
+
+func (i DocumentInfo) Words() int {
+	return i.words
+}
+
+func (i DocumentInfo) Characters() int {
+	return i.characters
+}
+
+func (i DocumentInfo) CharactersWithSpaces() int {
+	return i.charactersWithSpaces
+}
+
+func (k StyleKind) String() string {
+	return styleKindStr[k]
+}
+
+func (f ListNumberFormat) String() string {
+	return listNumberFormatStr[f]
+}
+
+func (k FieldKind) String() string {
+	return fieldKindStr[k]
+}
+
+func (k PictureFormatKind) String() string {
+	return pictureFormatKindStr[k]
+}
+
+// Name returns the control word naming the destination, empty if the group
+// did not start with one.
+func (d Destination) Name() string {
+	return d.wordToken.Text()
+}
+
+// Ignorable reports whether the destination was marked with \*
+func (d Destination) Ignorable() bool {
+	return d.ignorable
+}
+
+func (d Destination) Raw() string {
+	return d.raw
+}
+
+func (c TextFormat) FormatKind() TextFormatKind {
+	return c.formatKind
+}
+
+// Arg returns the numeric parameter of the format word, or -1 when the word
+// has none. For toggle properties like \b, -1 and any value other than 0 turn
+// the property on.
+func (c TextFormat) Arg() int {
+	return c.arg
+}
@@ -0,0 +1,1316 @@
+// Package parser turns the token stream of an RTF document into a flat list of
+// entities (control groups, control words, tables and text).
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"rtf-parser/lexer"
+)
+
+const (
+	ParsingErrorInvalidToken ParsingErrorKind = iota
+	ParsingErrorInvalidCharacterSet
+	ParsingErrorInvalidANSICodePage
+	ParsingErrorInvalidNumberConversion
+	ParsingErrorInvalidFormatKind
+	ParsingErrorInvalidPictureData
+)
+
+const (
+	defaultTextBufferCap    = 4
+	defaultUnicodeSkipCount = 1
+)
+
+type (
+	Parser struct {
+		opt              ParsingOptions
+		lexer            lexer.Lexer
+		previous         lexer.Token
+		current          lexer.Token
+		textEscapeTokens []lexer.TokenKind
+		codePage         int
+		unicodeSkipStack []int
+
+		// Set while the source of a destination is kept, which the lexer
+		// must not release
+		holdSource bool
+
+		// Tokens already peeked, so that peeking or consuming them again does
+		// not lex them again
+		lookahead lookahead
+	}
+
+	// lookahead holds the tokens lexed from one lexer position, with the
+	// position of the lexer after each of them. It only holds while the lexer
+	// has not moved since.
+	lookahead struct {
+		from   int
+		tokens []lexer.Token
+		ends   []int
+	}
+
+	ParsingErrorKind int
+
+	ParsingError struct {
+		kind  ParsingErrorKind
+		token lexer.Token
+		msg   string
+	}
+
+	ParsingOptions struct {
+		// KeepDestinations keeps the destination groups the parser does not
+		// understand as opaque Destination entities holding their raw source,
+		// instead of dropping them.
+		KeepDestinations bool
+	}
+
+	ControlWordParsingFn func(p *Parser, c ControlWord) (Entity, error)
+)
+
+var (
+	parsingErrorKindStr = map[ParsingErrorKind]string{
+		ParsingErrorInvalidToken:            "Invalid Token",
+		ParsingErrorInvalidCharacterSet:     "Invalid Character Set",
+		ParsingErrorInvalidANSICodePage:     "Invalid ANSI Code Page",
+		ParsingErrorInvalidNumberConversion: "Invalid Number Conversion",
+		ParsingErrorInvalidFormatKind:       "Invalid Format Kind",
+		ParsingErrorInvalidPictureData:      "Invalid Picture Data",
+	}
+
+	// Destinations that hold no document text, or text the parser cannot place
+	// yet. They are skipped even when not marked with \*
+	skippedDestinationLookup = map[string]bool{
+		"listtext":     true,
+		"pntext":       true,
+		"revtbl":       true,
+		"rsidtbl":      true,
+		"xmlnstbl":     true,
+		"filetbl":      true,
+		"nonshppict":   true,
+		"object":       true,
+		"fldinst":      true,
+		"header":       true,
+		"headerl":      true,
+		"headerr":      true,
+		"headerf":      true,
+		"footer":       true,
+		"footerl":      true,
+		"footerr":      true,
+		"footerf":      true,
+		"footnote":     true,
+		"annotation":   true,
+		"nonesttables": true,
+	}
+
+	// Control symbols that stand for a character of the text
+	textSymbolLookup = map[byte]rune{
+		'\\': '\\',
+		'{':  '{',
+		'}':  '}',
+		'~':  '\u00A0', // Non-breaking space
+		'_':  '\u2011', // Non-breaking hyphen
+		'-':  '\u00AD', // Optional hyphen
+	}
+
+	// Control words standing for a character of the surrounding text
+	textWordLookup = map[string]rune{
+		"emdash":    '\u2014',
+		"endash":    '\u2013',
+		"bullet":    '\u2022',
+		"lquote":    '\u2018',
+		"rquote":    '\u2019',
+		"ldblquote": '\u201C',
+		"rdblquote": '\u201D',
+		"emspace":   '\u2003',
+		"enspace":   '\u2002',
+		"qmspace":   '\u2005',
+		"zwj":       '\u200D',
+		"zwnj":      '\u200C',
+	}
+
+	controlWordFnLookup     map[string]ControlWordParsingFn
+	defaultTextEscapeTokens = []lexer.TokenKind{lexer.TokenOpenBracket, lexer.TokenCloseBracket, lexer.TokenBackslash}
+	fontTextEscapeTokens    = []lexer.TokenKind{lexer.TokenOpenBracket, lexer.TokenCloseBracket, lexer.TokenBackslash, lexer.TokenSemicolon}
+)
+
+func (e ParsingError) Error() string {
+	if e.msg == "" {
+		return fmt.Sprintf("%s: %q at offset %d", parsingErrorKindStr[e.kind], e.token.Text(), e.token.Start())
+	}
+	return e.msg
+}
+
+func (e ParsingError) Kind() ParsingErrorKind {
+	return e.kind
+}
+
+func (e ParsingError) Token() lexer.Token {
+	return e.token
+}
+
+func (parser *Parser) peek() lexer.Token {
+	return parser.peekAhead(1)
+}
+
+func (parser *Parser) peekNext() lexer.Token {
+	return parser.peekAhead(2)
+}
+
+// peekAhead returns the n-th upcoming token without consuming anything,
+// peekAhead(1) being the same as peek.
+func (parser *Parser) peekAhead(n int) lexer.Token {
+	idx := parser.lexer.Position()
+	ahead := &parser.lookahead
+	if ahead.from != idx {
+		ahead.from = idx
+		ahead.tokens = ahead.tokens[:0]
+		ahead.ends = ahead.ends[:0]
+	}
+
+	for len(ahead.tokens) < n {
+		if len(ahead.ends) > 0 {
+			parser.lexer.SetPosition(ahead.ends[len(ahead.ends)-1])
+		}
+		ahead.tokens = append(ahead.tokens, parser.lexer.NextToken())
+		ahead.ends = append(ahead.ends, parser.lexer.Position())
+	}
+	parser.lexer.SetPosition(idx)
+
+	return ahead.tokens[n-1]
+}
+
+func (parser *Parser) consume() lexer.Token {
+	parser.previous = parser.current
+
+	ahead := &parser.lookahead
+	if len(ahead.tokens) > 0 && ahead.from == parser.lexer.Position() {
+		parser.current = ahead.tokens[0]
+		parser.lexer.SetPosition(ahead.ends[0])
+
+		ahead.from = ahead.ends[0]
+		copy(ahead.tokens, ahead.tokens[1:])
+		copy(ahead.ends, ahead.ends[1:])
+		ahead.tokens = ahead.tokens[:len(ahead.tokens)-1]
+		ahead.ends = ahead.ends[:len(ahead.ends)-1]
+	} else {
+		parser.current = parser.lexer.NextToken()
+	}
+
+	// Nothing goes back before the current token
+	if !parser.holdSource {
+		parser.lexer.Release(parser.current.Start())
+	}
+
+	return parser.current
+}
+
+func (parser *Parser) expect(k lexer.TokenKind) error {
+	if parser.current.Kind() != k {
+		return ParsingError{
+			kind:  ParsingErrorInvalidToken,
+			token: parser.current,
+			msg:   fmt.Sprintf("Expected: %s, got: %s", k, parser.current.Kind()),
+		}
+	}
+
+	return nil
+}
+
+func (parser *Parser) expectNext(k lexer.TokenKind) error {
+	token := parser.consume()
+
+	if token.Kind() != k {
+		return ParsingError{
+			kind:  ParsingErrorInvalidToken,
+			token: token,
+			msg:   "Invalid Token Found",
+		}
+	}
+
+	return nil
+}
+
+func Parse(input string) ([]Entity, error) {
+	return ParseWithOptions(input, ParsingOptions{})
+}
+
+func ParseWithOptions(input string, options ParsingOptions) ([]Entity, error) {
+	parser := newParser(lexer.New(input), options)
+
+	ops := []Entity{}
+	for {
+		entity, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return []Entity{}, err
+		}
+
+		ops = append(ops, entity)
+	}
+
+	return ops, nil
+}
+
+// NewReader returns a parser reading the document from r as its entities are
+// pulled with Next. Only a bounded window of the input is held in memory, so
+// that large documents can be converted as they are read.
+func NewReader(r io.Reader, options ParsingOptions) *Parser {
+	return newParser(lexer.NewReader(r), options)
+}
+
+func newParser(l lexer.Lexer, options ParsingOptions) *Parser {
+	parser := &Parser{
+		opt:              options,
+		lexer:            l,
+		textEscapeTokens: defaultTextEscapeTokens,
+		codePage:         defaultCodePage,
+		unicodeSkipStack: []int{defaultUnicodeSkipCount},
+	}
+
+	return parser
+}
+
+// The lookup is built at init, as the parsing functions refer back to it. It
+// is read-only afterwards, so that documents can be parsed concurrently.
+func init() {
+	controlWordFnLookup = map[string]ControlWordParsingFn{
+		// Character set words
+		"ansi":    parseCharacterSet,
+		"ansicpg": parseCharacterSet,
+		"mac":     parseCharacterSet,
+		"pc":      parseCharacterSet,
+		"pca":     parseCharacterSet,
+		"fbidis":  parseCharacterSet,
+
+		// Unicode words
+		"u": parseUnicode,
+
+		// Special character words
+		"emdash":    parseTextWord,
+		"endash":    parseTextWord,
+		"bullet":    parseTextWord,
+		"lquote":    parseTextWord,
+		"rquote":    parseTextWord,
+		"ldblquote": parseTextWord,
+		"rdblquote": parseTextWord,
+		"emspace":   parseTextWord,
+		"enspace":   parseTextWord,
+		"qmspace":   parseTextWord,
+		"zwj":       parseTextWord,
+		"zwnj":      parseTextWord,
+		"uc":        parseUnicodeSkipCount,
+
+		// Font words
+		"fonttbl": parseFontTable,
+
+		// Picture words
+		"pict":    parsePicture,
+		"shppict": parseDestinationWord,
+
+		// Field words
+		"field": parseField,
+
+		// Style words
+		"stylesheet": parseStyleSheet,
+
+		// Document information words
+		"info": parseInfo,
+
+		// List words
+		"listtable":         parseListTable,
+		"listoverridetable": parseListOverrideTable,
+		"pn":                parseParagraphNumbering,
+
+		// Table words
+		"trowd":          parseTableRow,
+		"nesttableprops": parseDestinationWord,
+
+		// Color words
+		"colortbl": parseColorTable,
+		"red":      parseColorComponent,
+		"green":    parseColorComponent,
+		"blue":     parseColorComponent,
+		"alpha":    parseColorComponent,
+
+		// Text format words
+		"cf":         parseTextFormat,
+		"f":          parseTextFormat,
+		"fs":         parseTextFormat,
+		"li":         parseTextFormat,
+		"fi":         parseTextFormat,
+		"sb":         parseTextFormat,
+		"sa":         parseTextFormat,
+		"sbauto":     parseTextFormatOptionalArg,
+		"saauto":     parseTextFormatOptionalArg,
+		"sl":         parseTextFormat,
+		"slmult":     parseTextFormatOptionalArg,
+		"tab":        parseTextFormatNoArg,
+		"line":       parseTextFormatNoArg,
+		"tx":         parseTextFormat,
+		"tb":         parseTextFormat,
+		"tqc":        parseTextFormatNoArg,
+		"tqr":        parseTextFormatNoArg,
+		"tqdec":      parseTextFormatNoArg,
+		"tldot":      parseTextFormatNoArg,
+		"tlmdot":     parseTextFormatNoArg,
+		"tlhyph":     parseTextFormatNoArg,
+		"tlul":       parseTextFormatNoArg,
+		"tlth":       parseTextFormatNoArg,
+		"tleq":       parseTextFormatNoArg,
+		"i":          parseTextFormatOptionalArg,
+		"strike":     parseTextFormatOptionalArg,
+		"b":          parseTextFormatOptionalArg,
+		"ul":         parseTextFormatOptionalArg,
+		"uld":        parseTextFormatOptionalArg,
+		"uldash":     parseTextFormatOptionalArg,
+		"uldb":       parseTextFormatOptionalArg,
+		"ulwave":     parseTextFormatOptionalArg,
+		"ulw":        parseTextFormatOptionalArg,
+		"ulnone":     parseTextFormatNoArg,
+		"ulc":        parseTextFormat,
+		"highlight":  parseTextFormat,
+		"cb":         parseTextFormat,
+		"chcbpat":    parseTextFormat,
+		"cbpat":      parseTextFormat,
+		"intbl":      parseTextFormatNoArg,
+		"itap":       parseTextFormat,
+		"ls":         parseTextFormat,
+		"ilvl":       parseTextFormat,
+		"s":          parseTextFormat,
+		"cs":         parseTextFormat,
+		"ds":         parseTextFormat,
+		"cell":       parseTextFormatNoArg,
+		"nestcell":   parseTextFormatNoArg,
+		"row":        parseTextFormatNoArg,
+		"nestrow":    parseTextFormatNoArg,
+		"super":      parseTextFormatNoArg,
+		"sub":        parseTextFormatNoArg,
+		"nosupersub": parseTextFormatNoArg,
+		"up":         parseTextFormatOptionalArg,
+		"dn":         parseTextFormatOptionalArg,
+		"plain":      parseTextFormatNoArg,
+		"pard":       parseTextFormatNoArg,
+		"par":        parseTextFormatNoArg,
+		"qc":         parseTextFormatNoArg,
+		"qj":         parseTextFormatNoArg,
+		"qr":         parseTextFormatNoArg,
+	}
+}
+
+// Next returns the next entity of the document, and io.EOF once the input is
+// exhausted. Groups are not nested: their content comes between the
+// ControlGroup entities opening and closing them.
+func (parser *Parser) Next() (Entity, error) {
+	for {
+		token := parser.consume()
+
+		switch token.Kind() {
+		case lexer.TokenEOF:
+			if err := parser.lexer.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+
+		case lexer.TokenOpenBracket:
+			if parser.isSkippedDestination() {
+				dest := parser.parseDestination()
+				if parser.opt.KeepDestinations {
+					return dest, nil
+				}
+				continue
+			}
+			fallthrough
+		case lexer.TokenCloseBracket:
+			return parser.parseControlGroup(), nil
+
+		case lexer.TokenControlSymbol:
+			if !isTextSymbol(token) {
+				return parser.parseControlSymbol(), nil
+			}
+
+			text, err := parser.parseText()
+			if err != nil {
+				return nil, err
+			}
+			return text, nil
+
+		case lexer.TokenBackslash:
+			if isTextWord(parser.peek()) {
+				text, err := parser.parseText()
+				if err != nil {
+					return nil, err
+				}
+				return text, nil
+			}
+
+			word, err := parser.parseControlWord()
+			if err != nil {
+				return nil, err
+			}
+
+			return word, nil
+
+		case lexer.TokenNewline:
+			// Line breaks of the source are not part of the text
+
+		default:
+			text, err := parser.parseText()
+			if err != nil {
+				return nil, err
+			}
+			return text, nil
+		}
+	}
+}
+
+func (parser *Parser) parseControlGroup() ControlGroup {
+	group := ControlGroup{
+		token: parser.current,
+	}
+
+	// Each group inherits the \uc skip count of its parent and restores it
+	// when closed
+	last := len(parser.unicodeSkipStack) - 1
+	if parser.current.Kind() == lexer.TokenOpenBracket {
+		group.groupKind = ControlGroupKindBegin
+		parser.unicodeSkipStack = append(parser.unicodeSkipStack, parser.unicodeSkipStack[last])
+	} else {
+		group.groupKind = ControlGroupKindEnd
+		if last > 0 {
+			parser.unicodeSkipStack = parser.unicodeSkipStack[:last]
+		}
+	}
+
+	return group
+}
+
+// isSkippedDestination reports whether the group that was just opened is a
+// destination the parser does not understand: either one marked ignorable
+// with \* or one known to hold no document text.
+func (parser *Parser) isSkippedDestination() bool {
+	next := parser.peek()
+
+	if next.Kind() == lexer.TokenControlSymbol && next.Text() == `\*` {
+		word := parser.peekAhead(3)
+		if parser.peekNext().Kind() != lexer.TokenBackslash || word.Kind() != lexer.TokenString {
+			return true
+		}
+
+		_, supported := controlWordFnLookup[word.Text()]
+		return !supported
+	}
+
+	if next.Kind() == lexer.TokenBackslash {
+		word := parser.peekNext()
+		return word.Kind() == lexer.TokenString && skippedDestinationLookup[word.Text()]
+	}
+
+	return false
+}
+
+// peekGroupWord returns the control word starting the upcoming group, after
+// the \* marking it ignorable if any. The next token must be the opening
+// bracket of the group.
+func (parser *Parser) peekGroupWord() string {
+	n := 2
+	if next := parser.peekAhead(n); next.Kind() == lexer.TokenControlSymbol && next.Text() == `\*` {
+		n += 1
+	}
+
+	if parser.peekAhead(n).Kind() != lexer.TokenBackslash {
+		return ""
+	}
+
+	word := parser.peekAhead(n + 1)
+	if word.Kind() != lexer.TokenString {
+		return ""
+	}
+
+	return word.Text()
+}
+
+// parseDestination consumes a whole destination group, up to and including
+// its closing bracket. The opening bracket is the current token.
+func (parser *Parser) parseDestination() Destination {
+	dest := Destination{
+		startToken: parser.current,
+	}
+
+	// The source is only kept for destinations that are output
+	held := parser.holdSource
+	parser.holdSource = parser.opt.KeepDestinations
+
+	depth := 1
+	for depth > 0 {
+		token := parser.consume()
+
+		switch token.Kind() {
+		case lexer.TokenEOF:
+			depth = 0
+		case lexer.TokenOpenBracket:
+			depth += 1
+		case lexer.TokenCloseBracket:
+			depth -= 1
+		case lexer.TokenControlSymbol:
+			if token.Text() == `\*` {
+				dest.ignorable = true
+			}
+		case lexer.TokenString:
+			if dest.wordToken.Kind() == lexer.TokenInvalid && parser.previous.Kind() == lexer.TokenBackslash {
+				dest.wordToken = token
+			}
+		}
+	}
+
+	if parser.opt.KeepDestinations {
+		dest.raw = parser.lexer.Source(dest.startToken.Start(), parser.current.End())
+	}
+	parser.holdSource = held
+
+	return dest
+}
+
+// parseGroupText returns the text of a group, like a \fldinst instruction,
+// dropping its control words and the brackets of nested groups. Nested \*
+// destinations, like \fldtype or \datafield, are skipped with their text.
+// The opening bracket is the current token, the closing one is consumed.
+func (parser *Parser) parseGroupText() (string, error) {
+	builder := strings.Builder{}
+
+	depth := 1
+	for depth > 0 {
+		token := parser.consume()
+
+		switch token.Kind() {
+		case lexer.TokenEOF:
+			depth = 0
+		case lexer.TokenOpenBracket:
+			if next := parser.peek(); next.Kind() == lexer.TokenControlSymbol && next.Text() == `\*` {
+				parser.parseDestination()
+				continue
+			}
+			depth += 1
+		case lexer.TokenCloseBracket:
+			depth -= 1
+
+		case lexer.TokenBackslash:
+			if !isTextWord(parser.peek()) {
+				if err := parser.expectNext(lexer.TokenString); err != nil {
+					return "", err
+				}
+				if _, err := parser.parseOptionalNumber(); err != nil {
+					return "", err
+				}
+				continue
+			}
+
+			text, err := parser.parseText()
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(text.String())
+
+		case lexer.TokenControlSymbol:
+			if !isTextSymbol(token) {
+				continue
+			}
+			fallthrough
+		default:
+			text, err := parser.parseText()
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(text.String())
+		}
+	}
+
+	return builder.String(), nil
+}
+
+func (parser *Parser) parseControlWord() (Entity, error) {
+	word := ControlWord{
+		token: parser.current,
+	}
+
+	err := parser.expectNext(lexer.TokenString)
+
+	if err != nil {
+		return ControlWord{}, err
+	}
+
+	word.wordToken = parser.current
+
+	if fn, exist := controlWordFnLookup[parser.current.Text()]; exist {
+		return fn(parser, word)
+	}
+
+	// The parameter of an unknown word is not part of the text after it
+	if _, err := parser.parseOptionalNumber(); err != nil {
+		return ControlWord{}, err
+	}
+
+	return word, nil
+}
+
+func (parser *Parser) parseControlSymbol() Entity {
+	symbol := ControlSymbol{
+		token:  parser.current,
+		symbol: parser.current.Text()[1],
+	}
+
+	// A backslash at the end of a line is an implicit \par
+	if symbol.symbol == '\n' || symbol.symbol == '\r' {
+		return TextFormat{
+			ControlWord: ControlWord{
+				token:     parser.current,
+				wordToken: parser.current,
+			},
+			formatKind: TextFormatParagraphEnd,
+			arg:        -1,
+		}
+	}
+
+	return symbol
+}
+
+func (parser *Parser) parseText() (Text, error) {
+	text := Text{
+		leadingToken: parser.current,
+		tokens:       make([]lexer.Token, 0, defaultTextBufferCap),
+	}
+	builder := textBuilder{}
+
+parseSequence:
+	for {
+		err := parser.writeTextToken(&text, &builder)
+		if err != nil {
+			return Text{}, err
+		}
+
+		next := parser.peek()
+
+		if next.Kind() == lexer.TokenEOF {
+			break parseSequence
+		}
+
+		if next.Kind() == lexer.TokenBackslash && isTextWord(parser.peekNext()) {
+			parser.consume()
+			continue
+		}
+
+		if next.Kind() == lexer.TokenControlSymbol && !isTextSymbol(next) {
+			break parseSequence
+		}
+
+		for _, escape := range parser.textEscapeTokens {
+			if next.Kind() == escape {
+				break parseSequence
+			}
+		}
+
+		parser.consume()
+	}
+
+	builder.flushSurrogate()
+	text.value = builder.String()
+	return text, nil
+}
+
+// writeTextToken appends the text the current token stands for, decoding
+// \'hh escapes through the code page of the document and \uN escapes as
+// Unicode code points.
+func (parser *Parser) writeTextToken(text *Text, builder *textBuilder) error {
+	switch parser.current.Kind() {
+	case lexer.TokenBackslash:
+		entity, err := parser.parseControlWord()
+		if err != nil {
+			return err
+		}
+
+		u := entity.(Unicode)
+		text.tokens = append(text.tokens, u.wordToken)
+		builder.writeUTF16(u.value)
+
+	case lexer.TokenControlSymbol:
+		text.tokens = append(text.tokens, parser.current)
+		builder.writeRune(textSymbolLookup[parser.current.Text()[1]])
+
+	case lexer.TokenHexEscape:
+		text.tokens = append(text.tokens, parser.current)
+
+		// The token text is always of the form \'hh
+		b, _ := strconv.ParseUint(parser.current.Text()[2:], 16, 8)
+		builder.writeRune(decodeCodePageByte(parser.codePage, byte(b)))
+
+	case lexer.TokenNewline:
+		// Line breaks of the source are not part of the text
+
+	case lexer.TokenInvalid:
+		text.tokens = append(text.tokens, parser.current)
+
+		// Bytes outside ASCII are written as they are when they form UTF-8,
+		// and otherwise read through the code page of the document
+		value := parser.current.Text()
+		if utf8.ValidString(value) {
+			builder.writeString(value)
+			break
+		}
+		for i := 0; i < len(value); i += 1 {
+			builder.writeRune(decodeCodePageByte(parser.codePage, value[i]))
+		}
+
+	default:
+		text.tokens = append(text.tokens, parser.current)
+		builder.writeString(parser.current.Text())
+	}
+
+	return nil
+}
+
+// isTextSymbol reports whether a control symbol stands for a character of the
+// surrounding text rather than for a control word.
+func isTextSymbol(symbol lexer.Token) bool {
+	_, exist := textSymbolLookup[symbol.Text()[1]]
+	return exist
+}
+
+// isTextWord reports whether the token following a backslash makes it a
+// \uN escape or a special character word, which belong to the surrounding
+// text.
+func isTextWord(word lexer.Token) bool {
+	if word.Kind() != lexer.TokenString {
+		return false
+	}
+
+	_, exist := textWordLookup[word.Text()]
+	return exist || word.Text() == "u"
+}
+
+// textBuilder accumulates the decoded content of a Text entity. UTF-16
+// surrogate pairs written by consecutive \uN escapes are combined into a
+// single code point.
+type textBuilder struct {
+	strings.Builder
+	highSurrogate rune
+}
+
+func (b *textBuilder) writeUTF16(r rune) {
+	if utf16.IsSurrogate(r) {
+		if b.highSurrogate == 0 && r < 0xDC00 {
+			b.highSurrogate = r
+			return
+		}
+
+		if b.highSurrogate != 0 {
+			r = utf16.DecodeRune(b.highSurrogate, r)
+			b.highSurrogate = 0
+		}
+	}
+
+	b.writeRune(r)
+}
+
+func (b *textBuilder) writeRune(r rune) {
+	b.flushSurrogate()
+	b.WriteRune(r)
+}
+
+func (b *textBuilder) writeString(s string) {
+	b.flushSurrogate()
+	b.WriteString(s)
+}
+
+// flushSurrogate replaces a high surrogate that was not followed by a low one
+func (b *textBuilder) flushSurrogate() {
+	if b.highSurrogate != 0 {
+		b.WriteRune(utf8.RuneError)
+		b.highSurrogate = 0
+	}
+}
+
+func parseCharacterSet(parser *Parser, word ControlWord) (Entity, error) {
+	set := CharacterSet{
+		ControlWord: word,
+	}
+
+	var setExist bool
+	set.setKind, setExist = characterSetKindLookup[set.wordToken.Text()]
+
+	if !setExist {
+		return CharacterSet{}, ParsingError{
+			kind:  ParsingErrorInvalidCharacterSet,
+			token: set.wordToken,
+		}
+	}
+
+	if set.setKind == CharacterSetANSICPG {
+		err := parser.expectNext(lexer.TokenNumber)
+		if err != nil {
+			return CharacterSet{}, err
+		}
+
+		codePage, err := strconv.Atoi(parser.current.Text())
+
+		if err != nil {
+			return CharacterSet{}, ParsingError{
+				kind:  ParsingErrorInvalidANSICodePage,
+				token: set.wordToken,
+				msg:   err.Error(),
+			}
+		}
+
+		set.codePage = codePage
+	}
+
+	switch set.setKind {
+	case CharacterSetANSI:
+		parser.codePage = codePageANSI
+	case CharacterSetANSICPG:
+		parser.codePage = set.codePage
+	case CharacterSetMAC:
+		parser.codePage = codePageMac
+	case CharacterSetPC:
+		parser.codePage = codePagePC
+	case CharacterSetPCA:
+		parser.codePage = codePagePCA
+	}
+
+	return set, nil
+}
+
+func parseUnicode(parser *Parser, word ControlWord) (Entity, error) {
+	u := Unicode{
+		ControlWord: word,
+		skip:        parser.unicodeSkipStack[len(parser.unicodeSkipStack)-1],
+	}
+
+	value, err := parser.parseSignedNumber()
+	if err != nil {
+		return Unicode{}, err
+	}
+
+	// Code points above 32767 are written as negative 16 bit values
+	if value < 0 {
+		value += 65536
+	}
+	u.value = rune(value)
+
+	parser.skipUnicodeFallback(u.skip)
+
+	return u, nil
+}
+
+// parseTextWord parses a special character word like \emdash, given as the
+// Unicode character it stands for. Such words have no fallback to skip.
+func parseTextWord(parser *Parser, word ControlWord) (Entity, error) {
+	return Unicode{
+		ControlWord: word,
+		value:       textWordLookup[word.wordToken.Text()],
+	}, nil
+}
+
+func parseUnicodeSkipCount(parser *Parser, word ControlWord) (Entity, error) {
+	err := parser.expectNext(lexer.TokenNumber)
+	if err != nil {
+		return ControlWord{}, err
+	}
+
+	count, err := strconv.Atoi(parser.current.Text())
+	if err != nil {
+		return ControlWord{}, ParsingError{
+			token: parser.current,
+			kind:  ParsingErrorInvalidNumberConversion,
+		}
+	}
+
+	parser.unicodeSkipStack[len(parser.unicodeSkipStack)-1] = count
+
+	return word, nil
+}
+
+// skipUnicodeFallback drops the count characters following a \uN escape
+// that readers without Unicode support display instead. A \'hh escape or a
+// control word counts as a single character, and the fallback never extends
+// past the end of the current group.
+func (parser *Parser) skipUnicodeFallback(count int) {
+	for count > 0 {
+		next := parser.peek()
+
+		switch next.Kind() {
+		case lexer.TokenEOF, lexer.TokenOpenBracket, lexer.TokenCloseBracket:
+			return
+
+		case lexer.TokenNewline:
+			parser.consume()
+
+		case lexer.TokenHexEscape, lexer.TokenControlSymbol:
+			parser.consume()
+			count -= 1
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			if parser.peek().Kind() == lexer.TokenString {
+				parser.consume()
+				if parser.peek().Kind() == lexer.TokenDash {
+					parser.consume()
+				}
+				if parser.peek().Kind() == lexer.TokenNumber {
+					parser.consume()
+				}
+			}
+			count -= 1
+
+		default:
+			length := next.End() - next.Start()
+			if length > count {
+				// Only part of the token belongs to the fallback, the lexer
+				// resumes right after it
+				parser.lexer.SetPosition(next.Start() + count)
+				return
+			}
+
+			parser.consume()
+			count -= length
+		}
+	}
+}
+
+// parseSignedNumber consumes an optionally negative numeric parameter
+func (parser *Parser) parseSignedNumber() (int, error) {
+	nextToken := parser.consume()
+	negateNumber := false
+	if nextToken.Kind() == lexer.TokenDash {
+		negateNumber = true
+		parser.consume()
+	}
+
+	err := parser.expect(lexer.TokenNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(parser.current.Text())
+	if err != nil {
+		return 0, ParsingError{
+			token: parser.current,
+			kind:  ParsingErrorInvalidNumberConversion,
+		}
+	}
+
+	if negateNumber {
+		value = -value
+	}
+
+	return value, nil
+}
+
+// parseOptionalNumber consumes the numeric parameter of the current control
+// word if it has one, returning -1 otherwise. The parameter must directly
+// follow the word: in \b 42 the number is text.
+func (parser *Parser) parseOptionalNumber() (int, error) {
+	nextToken := parser.peek()
+	if nextToken.Start() != parser.current.End() {
+		return -1, nil
+	}
+
+	if nextToken.Kind() == lexer.TokenDash {
+		number := parser.peekNext()
+		if number.Kind() != lexer.TokenNumber || number.Start() != nextToken.End() {
+			return -1, nil
+		}
+	} else if nextToken.Kind() != lexer.TokenNumber {
+		return -1, nil
+	}
+
+	return parser.parseSignedNumber()
+}
+
+func parseFontTable(parser *Parser, word ControlWord) (Entity, error) {
+	tbl := FontTable{
+		ControlWord: word,
+	}
+
+parseFonts:
+	for {
+		nextToken := parser.peek()
+
+		if nextToken.Kind() != lexer.TokenOpenBracket {
+			break parseFonts
+		}
+
+		parser.consume()
+
+		f, err := parseFontTableEntry(parser)
+		if err != nil {
+			return FontTable{}, err
+		}
+
+		tbl.fonts = append(tbl.fonts, f)
+	}
+
+	return tbl, nil
+}
+
+func parseFontTableEntry(parser *Parser) (Entity, error) {
+	fnt := FontTableEntry{}
+
+	err := parser.expect(lexer.TokenOpenBracket)
+	if err != nil {
+		return FontTableEntry{}, err
+	}
+
+	parser.textEscapeTokens = fontTextEscapeTokens
+
+parseArgs:
+	for {
+		nextToken := parser.consume()
+
+		switch nextToken.Kind() {
+		case lexer.TokenSemicolon:
+			break parseArgs
+		case lexer.TokenCloseBracket:
+			// The entry ends without a semicolon
+			parser.textEscapeTokens = defaultTextEscapeTokens
+			return fnt, nil
+		case lexer.TokenOpenBracket:
+			// \*\panose, \*\falt, ... groups
+			parser.parseDestination()
+			continue
+		case lexer.TokenBackslash:
+			if !isTextWord(parser.peek()) {
+				break
+			}
+			fallthrough
+		case lexer.TokenControlSymbol:
+			if nextToken.Kind() == lexer.TokenControlSymbol && !isTextSymbol(nextToken) {
+				continue
+			}
+			fallthrough
+		case lexer.TokenHexEscape, lexer.TokenString, lexer.TokenNumber, lexer.TokenInvalid, lexer.TokenDash:
+			fnt.fontName, err = parser.parseText()
+			if err != nil {
+				return FontTableEntry{}, err
+			}
+			fallthrough
+		case lexer.TokenWhitespace, lexer.TokenNewline:
+			continue
+		}
+
+		err = parser.expect(lexer.TokenBackslash)
+		if err != nil {
+			return FontTableEntry{}, err
+		}
+
+		err = parser.expectNext(lexer.TokenString)
+		if err != nil {
+			return FontTableEntry{}, err
+		}
+
+		switch parser.current.Text() {
+		case "f":
+			err = parser.expectNext(lexer.TokenNumber)
+			if err != nil {
+				return FontTableEntry{}, err
+			}
+
+			fnt.index, err = strconv.Atoi(parser.current.Text())
+			if err != nil {
+				return FontTableEntry{}, ParsingError{
+					token: parser.current,
+					kind:  ParsingErrorInvalidNumberConversion,
+				}
+			}
+
+		case "fnil":
+			fnt.defaultFallback = true
+
+		case "fcharset":
+			err = parser.expectNext(lexer.TokenNumber)
+			if err != nil {
+				return FontTableEntry{}, err
+			}
+
+			fnt.charset, err = strconv.Atoi(parser.current.Text())
+			if err != nil {
+				return FontTableEntry{}, ParsingError{
+					token: parser.current,
+					kind:  ParsingErrorInvalidNumberConversion,
+				}
+			}
+
+		default:
+			// \froman, \fprq2, \fbidi, ... do not change the font used
+			if _, err := parser.parseOptionalNumber(); err != nil {
+				return FontTableEntry{}, err
+			}
+		}
+	}
+
+	err = parser.expectNext(lexer.TokenCloseBracket)
+	if err != nil {
+		return FontTableEntry{}, err
+	}
+	parser.textEscapeTokens = defaultTextEscapeTokens
+
+	return fnt, nil
+}
+
+func parseColorTable(parser *Parser, word ControlWord) (Entity, error) {
+	table := ColorTable{
+		ControlWord: word,
+	}
+
+parseColors:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseColors
+		case lexer.TokenSemicolon:
+			parser.consume()
+			for parser.peek().Kind() == lexer.TokenNewline || parser.peek().Kind() == lexer.TokenWhitespace {
+				parser.consume()
+			}
+			if parser.peek().Kind() != lexer.TokenCloseBracket {
+				clr, err := parseColorTableEntry(parser)
+				if err != nil {
+					return ColorTable{}, err
+				}
+
+				table.colors = append(table.colors, clr)
+			}
+		default:
+			// Line breaks between the entries
+			parser.consume()
+		}
+	}
+
+	return table, nil
+}
+
+func parseColorComponent(parser *Parser, word ControlWord) (Entity, error) {
+	component := ColorComponent{
+		ControlWord: word,
+	}
+
+	err := parser.expectNext(lexer.TokenNumber)
+	if err != nil {
+		return ColorComponent{}, err
+	}
+
+	value, err := strconv.Atoi(parser.current.Text())
+	if err != nil {
+		return ColorComponent{}, ParsingError{
+			token: parser.current,
+			kind:  ParsingErrorInvalidNumberConversion,
+		}
+	}
+
+	component.value = uint8(value)
+
+	return component, nil
+}
+
+func parseTextFormat(parser *Parser, word ControlWord) (Entity, error) {
+	format := TextFormat{
+		ControlWord: word,
+	}
+
+	formatKind, exist := textFormatKindLookup[format.wordToken.Text()]
+	if !exist {
+		return TextFormat{}, ParsingError{
+			token: format.wordToken,
+			kind:  ParsingErrorInvalidFormatKind,
+		}
+	}
+
+	format.formatKind = formatKind
+
+	value, err := parser.parseSignedNumber()
+	if err != nil {
+		return TextFormat{}, err
+	}
+
+	format.arg = value
+
+	return format, nil
+}
+
+func parseTextFormatNoArg(parser *Parser, word ControlWord) (Entity, error) {
+	format := TextFormat{
+		ControlWord: word,
+	}
+
+	formatKind, exist := textFormatKindLookup[format.wordToken.Text()]
+	if !exist {
+		return TextFormat{}, ParsingError{
+			token: format.wordToken,
+			kind:  ParsingErrorInvalidFormatKind,
+		}
+	}
+
+	format.formatKind = formatKind
+	format.arg = -1
+	return format, nil
+}
+
+// parseTextFormatOptionalArg parses a format word whose parameter may be left
+// out, like toggle properties: \b turns bold on and \b0 turns it off.
+func parseTextFormatOptionalArg(parser *Parser, word ControlWord) (Entity, error) {
+	entity, err := parseTextFormatNoArg(parser, word)
+	if err != nil {
+		return TextFormat{}, err
+	}
+
+	format := entity.(TextFormat)
+	format.arg, err = parser.parseOptionalNumber()
+	if err != nil {
+		return TextFormat{}, err
+	}
+
+	return format, nil
+}
+
+// parseDestinationWord accepts a destination whose content is parsed like the
+// rest of the document, so that it is not skipped as an unknown one.
+func parseDestinationWord(parser *Parser, word ControlWord) (Entity, error) {
+	return word, nil
+}
+
+func parseColorTableEntry(parser *Parser) (Entity, error) {
+	clr := ColorTableEntry{
+		startToken: parser.current,
+	}
+
+parseComponents:
+	for i := 0; i < 4; i += 1 {
+		nextToken := parser.peek()
+
+		if nextToken.Kind() == lexer.TokenSemicolon {
+			break parseComponents
+		}
+
+		err := parser.expectNext(lexer.TokenBackslash)
+		if err != nil {
+			return ColorTable{}, err
+		}
+
+		channel, err := parser.parseControlWord()
+		if err != nil {
+			return ColorTable{}, err
+		}
+
+		clr.channels[i] = channel
+	}
+
+	return clr, nil
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"rtf-parser/lexer"
+)
+
+// parseWithTimeout pulls every entity of a parser, failing the test when
+// parsing does not end instead of hanging the whole test binary. The end of
+// the input gives a nil error.
+func parseWithTimeout(t *testing.T, p *Parser) ([]Entity, error) {
+	t.Helper()
+
+	type result struct {
+		ops []Entity
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ops := []Entity{}
+		for {
+			entity, err := p.Next()
+			if err == io.EOF {
+				done <- result{ops: ops}
+				return
+			}
+			if err != nil {
+				done <- result{ops: ops, err: err}
+				return
+			}
+			ops = append(ops, entity)
+		}
+	}()
+
+	select {
+	case r := <-done:
+		return r.ops, r.err
+	case <-time.After(10 * time.Second):
+		t.Fatalf("parsing did not end")
+		return nil, nil
+	}
+}
+
+// stringParser returns a parser of an input held in memory, as Parse uses
+func stringParser(input string) *Parser {
+	return newParser(lexer.New(input), ParsingOptions{})
+}
+
+func TestParseTextAtEOF(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		text  string
+	}{
+		{name: "text", input: `{\rtf1\pard a\par}abc`, text: "abc"},
+		{name: "space", input: `{\rtf1\pard a\par} `, text: " "},
+		{name: "line break and space", input: "{\\rtf1\\pard a\\par}\r\n ", text: " "},
+		{name: "NUL", input: "{\\rtf1\\pard a\\par}\x00", text: "\x00"},
+		{name: "escaped close bracket", input: `{\rtf1\pard a\par}\}`, text: "}"},
+		{name: "escaped open bracket", input: `{\rtf1\pard a\par}\{`, text: "{"},
+		{name: "escaped backslash", input: `{\rtf1\pard a\par}\\`, text: `\`},
+		{name: "unterminated group", input: `{\rtf1\pard a`, text: "a"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := parseWithTimeout(t, stringParser(test.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			last, ok := ops[len(ops)-1].(Text)
+			if !ok {
+				t.Fatalf("last entity is %T, want Text", ops[len(ops)-1])
+			}
+			if last.String() != test.text {
+				t.Errorf("text = %q, want %q", last.String(), test.text)
+			}
+		})
+	}
+}
+
+func TestParseFontNameAtEOF(t *testing.T) {
+	// A font name cut by the end of the input must not hang the font table
+	if _, err := parseWithTimeout(t, stringParser(`{\rtf1{\fonttbl{\f0 Arial`)); err == nil {
+		t.Errorf("expected an error for a truncated font table")
+	}
+}
+
+func TestParseConcurrently(t *testing.T) {
+	input := `{\rtf1\ansi{\fonttbl{\f0 Arial;}}\pard\f0\b bold\b0  text\par}`
+
+	want, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []Entity)
+	for i := 0; i < 8; i += 1 {
+		go func() {
+			ops, _ := Parse(input)
+			done <- ops
+		}()
+	}
+	for i := 0; i < 8; i += 1 {
+		if ops := <-done; len(ops) != len(want) {
+			t.Errorf("got %d entities, want %d", len(ops), len(want))
+		}
+	}
+}
+
+func TestParseFontTableEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		font  string
+	}{
+		{name: "panose", input: `{\f0\fnil\fcharset0{\*\panose 02020603050405020304}Times New Roman;}`, font: "Times New Roman"},
+		{name: "alternate name", input: `{\f0\froman\fcharset0\fprq2 Cambria{\*\falt Arial};}`, font: "Cambria"},
+		{name: "family words", input: `{\f0\fbidi\fswiss\fprq2\fcharset0 Calibri;}`, font: "Calibri"},
+		{name: "no semicolon", input: `{\f0\fnil Arial}`, font: "Arial"},
+		{name: "unicode name", input: `{\f0\fnil \u23435?\u20307?;}`, font: "\u5b8b\u4f53"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := parseWithTimeout(t, stringParser(`{\rtf1\ansi{\fonttbl`+test.input+`}\pard a\par}`))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var table FontTable
+			for _, op := range ops {
+				if tbl, ok := op.(FontTable); ok {
+					table = tbl
+				}
+			}
+			if len(table.fonts) != 1 {
+				t.Fatalf("got %d fonts, want 1", len(table.fonts))
+			}
+
+			font := table.fonts[0].(FontTableEntry)
+			if name := font.fontName.String(); name != test.font {
+				t.Errorf("font name = %q, want %q", name, test.font)
+			}
+		})
+	}
+}
+
+func TestParseStyleNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		style   string
+		formats int
+	}{
+		{name: "plain name", input: `{\s1\b\sbasedon0 heading 1;}`, style: "heading 1", formats: 1},
+		{name: "unicode name", input: `{\s1\b \u26631?\u-26472? 1;}`, style: "标题 1", formats: 1},
+		{name: "hex escapes", input: `{\s1 Titre caf\'e9;}`, style: "Titre café"},
+		{name: "special characters", input: `{\s1 Note\~\endash\~1;}`, style: "Note – 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := parseWithTimeout(t, stringParser(`{\rtf1\ansi{\stylesheet`+test.input+`}\pard a\par}`))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var sheet StyleSheet
+			for _, op := range ops {
+				if s, ok := op.(StyleSheet); ok {
+					sheet = s
+				}
+			}
+			if len(sheet.Styles()) != 1 {
+				t.Fatalf("got %d styles, want 1", len(sheet.Styles()))
+			}
+
+			style := sheet.Styles()[0].(Style)
+			if style.Name() != test.style {
+				t.Errorf("style name = %q, want %q", style.Name(), test.style)
+			}
+			if len(style.Formats()) != test.formats {
+				t.Errorf("got %d formats, want %d", len(style.Formats()), test.formats)
+			}
+		})
+	}
+}
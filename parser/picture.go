@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"rtf-parser/lexer"
+)
+
+const (
+	defaultPictureScale = 100
+)
+
+// parsePicture parses the content of a \pict group up to its closing bracket,
+// which is left to close the group. Unknown properties and nested groups
+// (\*\blipuid, \*\picprop, ...) are skipped.
+func parsePicture(parser *Parser, word ControlWord) (Entity, error) {
+	pict := Picture{
+		ControlWord: word,
+		scaleX:      defaultPictureScale,
+		scaleY:      defaultPictureScale,
+	}
+	hexData := strings.Builder{}
+
+parseContent:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseContent
+
+		case lexer.TokenOpenBracket:
+			parser.consume()
+			parser.parseDestination()
+
+		case lexer.TokenString, lexer.TokenNumber:
+			parser.consume()
+			hexData.WriteString(nextToken.Text())
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			err := parser.expectNext(lexer.TokenString)
+			if err != nil {
+				return Picture{}, err
+			}
+
+			name := parser.current.Text()
+			arg, err := parser.parseOptionalNumber()
+			if err != nil {
+				return Picture{}, err
+			}
+
+			if kind, exist := pictureFormatKindLookup[name]; exist {
+				pict.formatKind = kind
+				continue
+			}
+
+			switch name {
+			case "picw":
+				pict.width = arg
+			case "pich":
+				pict.height = arg
+			case "picwgoal":
+				pict.goalWidth = arg
+			case "pichgoal":
+				pict.goalHeight = arg
+			case "picscalex":
+				pict.scaleX = arg
+			case "picscaley":
+				pict.scaleY = arg
+			case "bin":
+				pict.data = parser.consumeBinary(arg)
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+
+	if pict.data == nil {
+		// Writers may break the hex digits anywhere, an odd trailing digit
+		// is dropped
+		digits := hexData.String()
+		data, err := hex.DecodeString(digits[:len(digits)&^1])
+		if err != nil {
+			return Picture{}, ParsingError{
+				token: word.wordToken,
+				kind:  ParsingErrorInvalidPictureData,
+			}
+		}
+		pict.data = data
+	}
+
+	return pict, nil
+}
+
+// consumeBinary reads the raw bytes following \binN, after the space that
+// delimits the control word.
+func (parser *Parser) consumeBinary(length int) []byte {
+	// The lexer is past the space delimiting the parameter of \binN
+	return parser.lexer.ReadBytes(length)
+}
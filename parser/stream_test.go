@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"rtf-parser/internal/samples"
+)
+
+// streamInputs returns the sample documents, along with a generated one long
+// enough for the window of the lexer to move several times.
+func streamInputs(t *testing.T) map[string]string {
+	t.Helper()
+
+	inputs := samples.Inputs(t)
+
+	builder := strings.Builder{}
+	builder.WriteString(`{\rtf1\ansi\ansicpg1252{\fonttbl{\f0\fnil\fcharset0 Arial;}}{\colortbl;\red255\green0\blue0;}` + "\n")
+	for i := 0; i < 5000; i += 1 {
+		fmt.Fprintf(&builder, "\\pard\\f0\\fs%d \\b %d apples\\b0 , caf\\'e9 \\u8212? x\\\\y {\\*\\unknown kept %d}\\par\r\n", 20+i%10, i, i)
+		if i%500 == 0 {
+			builder.WriteString(`{\pict\pngblip\picw10\pich10 89504e470d0a}`)
+			builder.WriteString("{\\pict\\pngblip\\bin6 \x89PNG\r\n}")
+		}
+	}
+	builder.WriteString("}")
+	inputs["generated"] = builder.String()
+
+	return inputs
+}
+
+func TestStreamMatchesParse(t *testing.T) {
+	readers := map[string]func(string) io.Reader{
+		"full":     func(s string) io.Reader { return strings.NewReader(s) },
+		"one byte": func(s string) io.Reader { return iotest.OneByteReader(strings.NewReader(s)) },
+		"half":     func(s string) io.Reader { return iotest.HalfReader(strings.NewReader(s)) },
+	}
+
+	for name, input := range streamInputs(t) {
+		for _, keep := range []bool{false, true} {
+			options := ParsingOptions{KeepDestinations: keep}
+
+			want, err := ParseWithOptions(input, options)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			for readerName, reader := range readers {
+				t.Run(fmt.Sprintf("%s/%s/keep=%t", name, readerName, keep), func(t *testing.T) {
+					got, err := parseWithTimeout(t, NewReader(reader(input), options))
+					if err != nil {
+						t.Fatalf("stream ended with %v", err)
+					}
+					if len(got) != len(want) {
+						t.Fatalf("got %d entities, want %d", len(got), len(want))
+					}
+					for i := range want {
+						if !reflect.DeepEqual(got[i], want[i]) {
+							t.Fatalf("entity %d = %#v, want %#v", i, got[i], want[i])
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	input := streamInputs(t)["generated"]
+
+	// Cutting the input anywhere, even in a group or a word, still ends the
+	// stream
+	for _, length := range []int{1, 10, 100, len(input) / 3, len(input) / 2, len(input) - 1} {
+		p := NewReader(iotest.HalfReader(strings.NewReader(input[:length])), ParsingOptions{})
+		if _, err := parseWithTimeout(t, p); err != nil {
+			var parsingErr ParsingError
+			if !errors.As(err, &parsingErr) {
+				t.Errorf("input cut at %d: stream ended with %v", length, err)
+			}
+		}
+	}
+}
+
+func TestStreamReadError(t *testing.T) {
+	failure := errors.New("read failure")
+	r := io.MultiReader(strings.NewReader(`{\rtf1\pard a`), iotest.ErrReader(failure))
+
+	_, err := parseWithTimeout(t, NewReader(r, ParsingOptions{}))
+	if !errors.Is(err, failure) {
+		t.Errorf("stream ended with %v, want the read error", err)
+	}
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"strings"
+
+	"rtf-parser/lexer"
+)
+
+const (
+	// \sbasedon value of the styles that inherit from no other
+	styleBasedOnNone = 222
+)
+
+// parseStyleSheet parses the style groups of a \stylesheet, up to the closing
+// bracket of the stylesheet which is left to close the group.
+func parseStyleSheet(parser *Parser, word ControlWord) (Entity, error) {
+	sheet := StyleSheet{
+		ControlWord: word,
+	}
+
+parseStyles:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF, lexer.TokenCloseBracket:
+			break parseStyles
+
+		case lexer.TokenOpenBracket:
+			parser.consume()
+			style, err := parseStyle(parser)
+			if err != nil {
+				return StyleSheet{}, err
+			}
+			sheet.styles = append(sheet.styles, style)
+
+		default:
+			parser.consume()
+		}
+	}
+
+	return sheet, nil
+}
+
+// parseStyle parses a style definition, the opening bracket being the current
+// token. The closing bracket is consumed. A definition is made of the index
+// and inheritance words, the formatting of the style and its name, ending
+// with a semicolon.
+func parseStyle(parser *Parser) (Entity, error) {
+	style := Style{
+		startToken: parser.current,
+		basedOn:    -1,
+		next:       -1,
+	}
+
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenEOF:
+			return style, nil
+		case lexer.TokenCloseBracket:
+			parser.consume()
+			if style.next == -1 {
+				style.next = style.index
+			}
+			return style, nil
+
+		case lexer.TokenOpenBracket:
+			// \*\keycode, ...
+			parser.consume()
+			parser.parseDestination()
+
+		case lexer.TokenBackslash:
+			parser.consume()
+			// \uN escapes and special characters belong to the name
+			if isTextWord(parser.peek()) {
+				if err := parser.parseStyleName(&style); err != nil {
+					return Style{}, err
+				}
+				continue
+			}
+
+			entity, err := parser.parseStyleWord(&style)
+			if err != nil {
+				return Style{}, err
+			}
+
+			if format, ok := entity.(TextFormat); ok {
+				style.formats = append(style.formats, format)
+			}
+
+		case lexer.TokenControlSymbol:
+			parser.consume()
+			if isTextSymbol(nextToken) {
+				if err := parser.parseStyleName(&style); err != nil {
+					return Style{}, err
+				}
+			}
+
+		case lexer.TokenString, lexer.TokenNumber, lexer.TokenHexEscape, lexer.TokenInvalid, lexer.TokenDash:
+			parser.consume()
+			if err := parser.parseStyleName(&style); err != nil {
+				return Style{}, err
+			}
+
+		default:
+			parser.consume()
+		}
+	}
+}
+
+// parseStyleName parses the name of a style up to its semicolon, the first
+// token of the name being the current token.
+func (parser *Parser) parseStyleName(style *Style) error {
+	parser.textEscapeTokens = fontTextEscapeTokens
+	name, err := parser.parseText()
+	parser.textEscapeTokens = defaultTextEscapeTokens
+	if err != nil {
+		return err
+	}
+
+	style.name = strings.TrimSpace(name.String())
+	return nil
+}
+
+// parseStyleWord parses a control word of a style definition. The words of
+// the style itself update it, formatting words are parsed as in the document
+// and returned. The backslash is the current token.
+func (parser *Parser) parseStyleWord(style *Style) (Entity, error) {
+	word := ControlWord{
+		token: parser.current,
+	}
+
+	if err := parser.expectNext(lexer.TokenString); err != nil {
+		return nil, err
+	}
+	word.wordToken = parser.current
+	name := parser.current.Text()
+
+	if kind, exist := styleKindLookup[name]; exist {
+		index, err := parser.parseOptionalNumber()
+		if err != nil {
+			return nil, err
+		}
+		style.styleKind = kind
+		style.index = max(index, 0)
+		return word, nil
+	}
+
+	switch name {
+	case "sbasedon":
+		basedOn, err := parser.parseOptionalNumber()
+		if err != nil {
+			return nil, err
+		}
+		if basedOn != styleBasedOnNone {
+			style.basedOn = basedOn
+		}
+		return word, nil
+
+	case "snext":
+		next, err := parser.parseOptionalNumber()
+		if err != nil {
+			return nil, err
+		}
+		style.next = next
+		return word, nil
+
+	case "additive":
+		style.additive = true
+		return word, nil
+	}
+
+	if fn, exist := controlWordFnLookup[name]; exist {
+		return fn(parser, word)
+	}
+
+	// Skip the parameter of the words that are not understood
+	if _, err := parser.parseOptionalNumber(); err != nil {
+		return nil, err
+	}
+
+	return word, nil
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"strings"
+
+	"rtf-parser/lexer"
+)
+
+var (
+	// Row and cell definition words that do not share one of the prefixes
+	// accepted by isTableDefinitionWord
+	tableDefinitionWords = map[string]bool{
+		"cellx":    true,
+		"ts":       true,
+		"irow":     true,
+		"irowband": true,
+		"lastrow":  true,
+		"ltrrow":   true,
+		"rtlrow":   true,
+	}
+
+	tableDefinitionPrefixes = []string{"tr", "cl", "brdr", "tbl"}
+)
+
+// isTableDefinitionWord reports whether a control word belongs to a table row
+// definition. Besides the words handled by parseTableRow, Word writes many
+// layout hints (\trftsWidth, \clpadl, \tblind, ...) that are consumed with
+// the definition so they do not end it early.
+func isTableDefinitionWord(word string) bool {
+	if word == "trowd" {
+		return false
+	}
+
+	if tableDefinitionWords[word] {
+		return true
+	}
+
+	for _, prefix := range tableDefinitionPrefixes {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTableRow parses a row definition, from \trowd to the last row or cell
+// property that follows it.
+func parseTableRow(parser *Parser, word ControlWord) (Entity, error) {
+	row := TableRow{
+		ControlWord: word,
+	}
+	cell := TableCell{
+		startToken: word.token,
+	}
+
+	// The border the \brdr words currently apply to, nil for the row borders
+	// which are not kept
+	var border *TableBorder
+
+parseProperties:
+	for {
+		nextToken := parser.peek()
+
+		switch nextToken.Kind() {
+		case lexer.TokenWhitespace, lexer.TokenNewline:
+			parser.consume()
+			continue
+		case lexer.TokenBackslash:
+		default:
+			break parseProperties
+		}
+
+		wordToken := parser.peekNext()
+		if wordToken.Kind() != lexer.TokenString || !isTableDefinitionWord(wordToken.Text()) {
+			break parseProperties
+		}
+
+		startToken := parser.consume()
+		parser.consume()
+
+		arg, err := parser.parseOptionalNumber()
+		if err != nil {
+			return TableRow{}, err
+		}
+
+		name := wordToken.Text()
+		switch name {
+		case "trleft":
+			row.left = arg
+		case "trgaph":
+			row.gap = arg
+		case "trrh":
+			row.height = arg
+		case "trql":
+			row.alignKind = TableRowAlignLeft
+		case "trqc":
+			row.alignKind = TableRowAlignCenter
+		case "trqr":
+			row.alignKind = TableRowAlignRight
+		case "trhdr":
+			row.header = true
+
+		case "clvertalt":
+			cell.alignKind = TableCellAlignTop
+		case "clvertalc":
+			cell.alignKind = TableCellAlignCenter
+		case "clvertalb":
+			cell.alignKind = TableCellAlignBottom
+		case "clmgf":
+			cell.mergeKind = TableCellMergeFirst
+		case "clmrg":
+			cell.mergeKind = TableCellMergeContinue
+		case "clvmgf":
+			cell.verticalMerge = TableCellMergeFirst
+		case "clvmrg":
+			cell.verticalMerge = TableCellMergeContinue
+		case "clcbpat":
+			cell.shading = arg
+
+		case "cellx":
+			cell.boundary = arg
+			row.cells = append(row.cells, cell)
+			cell = TableCell{
+				startToken: startToken,
+			}
+			border = nil
+
+		case "brdrw":
+			if border != nil {
+				border.width = arg
+			}
+		case "brdrcf":
+			if border != nil {
+				border.color = arg
+			}
+
+		default:
+			if side, exist := tableBorderSideLookup[name]; exist {
+				border = &cell.borders[side]
+			} else if styleKind, exist := borderStyleKindLookup[name]; exist {
+				if border != nil {
+					border.styleKind = styleKind
+				}
+			} else if strings.HasPrefix(name, "trbrdr") {
+				border = nil
+			}
+		}
+	}
+
+	return row, nil
+}
@@ -0,0 +1,30 @@
+// Package rtf converts RTF documents to HTML.
+//
+// The conversion runs in three stages, each available as its own package:
+// lexer splits the input into tokens, parser turns them into a flat list of
+// entities, layout resolves those entities into a tree of formatted nodes and
+// html renders that tree. ConvertHTML chains all of them for the common case.
+package rtf
+
+import (
+	"rtf-parser/html"
+	"rtf-parser/layout"
+	"rtf-parser/parser"
+)
+
+// ConvertHTML parses an RTF document and renders it as an HTML fragment, or
+// as a full document described by its \info group with FullDocument.
+func ConvertHTML(input string, options html.BuilderOptions) (string, error) {
+	ops, err := parser.Parse(input)
+	if err != nil {
+		return "", err
+	}
+
+	if options.FullDocument && options.Info == nil {
+		if info, exist := parser.FindDocumentInfo(ops); exist {
+			options.Info = &info
+		}
+	}
+
+	return html.OutputHTML(layout.BuildLayout(ops), options)
+}